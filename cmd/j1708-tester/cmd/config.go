@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/j1708-tester/pkg/common"
+	"github.com/syncromatics/j1708-tester/pkg/simma"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type config struct {
+	Port        int              `yaml:"port"`
+	SourceMid   int              `yaml:"sourceMid"`
+	Definitions []string         `yaml:"definitions"`
+	Log         logConfig        `yaml:"log"`
+	Schedule    []scheduleConfig `yaml:"schedule"`
+	Simulate    simulateConfig   `yaml:"simulate"`
+	Device      deviceConfig     `yaml:"device"`
+}
+
+type simulateConfig struct {
+	Ecus  []ecuConfig  `yaml:"ecus"`
+	Rules []ruleConfig `yaml:"rules"`
+}
+
+// ecuConfig is a simulated ECU and the values it answers requests with, in
+// the data syntax of a message expression.
+type ecuConfig struct {
+	Mid        int            `yaml:"mid"`
+	Parameters map[int]string `yaml:"parameters"`
+}
+
+// ruleConfig replies with a message expression when the PID is received from
+// the MID, or from any MID when from is left out.
+type ruleConfig struct {
+	From  *int   `yaml:"from"`
+	Pid   int    `yaml:"pid"`
+	Reply string `yaml:"reply"`
+}
+
+type scheduleConfig struct {
+	Interval time.Duration `yaml:"interval"`
+	Message  string        `yaml:"message"`
+}
+
+type logConfig struct {
+	File   string `yaml:"file"`
+	Format string `yaml:"format"`
+}
+
+type deviceConfig struct {
+	Path            string        `yaml:"path"`
+	Mode            string        `yaml:"mode"`
+	BaudRate        uint          `yaml:"baudRate"`
+	DataBits        uint          `yaml:"dataBits"`
+	StopBits        uint          `yaml:"stopBits"`
+	MinimumReadSize uint          `yaml:"minimumReadSize"`
+	PassAll         passAllConfig `yaml:"passAll"`
+}
+
+type passAllConfig struct {
+	Port  int  `yaml:"port"`
+	J1708 bool `yaml:"j1708"`
+	J1587 bool `yaml:"j1587"`
+	CAN   bool `yaml:"can"`
+	J1939 bool `yaml:"j1939"`
+}
+
+func defaultConfig() *config {
+	serial := simma.DefaultSerialConfig(*getDefaultDevice())
+	passAll := simma.DefaultPassAllMode()
+
+	return &config{
+		Port:      8080,
+		SourceMid: common.DefaultSourceMid,
+		Log: logConfig{
+			Format: "compact",
+		},
+		Device: deviceConfig{
+			Path:            serial.Port,
+			Mode:            simma.J1587Mode.String(),
+			BaudRate:        serial.BaudRate,
+			DataBits:        serial.DataBits,
+			StopBits:        serial.StopBits,
+			MinimumReadSize: serial.MinimumReadSize,
+			PassAll: passAllConfig{
+				Port:  passAll.Port,
+				J1708: passAll.J1708,
+				J1587: passAll.J1587,
+				CAN:   passAll.CAN,
+				J1939: passAll.J1939,
+			},
+		},
+	}
+}
+
+// loadConfig builds the configuration from the defaults, then the config
+// file if one is given, then any flags set on the command line.
+func loadConfig(cmd *cobra.Command) (*config, error) {
+	c := defaultConfig()
+
+	if *configFile != "" {
+		b, err := ioutil.ReadFile(*configFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed reading config file '%s'", *configFile)
+		}
+
+		err = yaml.UnmarshalStrict(b, c)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed parsing config file '%s'", *configFile)
+		}
+	}
+
+	flags := cmd.Flags()
+	if flags.Changed("port") {
+		c.Port = *port
+	}
+	if flags.Changed("mid") {
+		c.SourceMid = *sourceMid
+	}
+	if flags.Changed("definitions") {
+		c.Definitions = *definitions
+	}
+	if flags.Changed("log-file") {
+		c.Log.File = *logFile
+	}
+	if flags.Changed("log-format") {
+		c.Log.Format = *logFormat
+	}
+	if flags.Changed("schedule") {
+		c.Schedule = []scheduleConfig{}
+		for _, s := range *schedule {
+			interval, message := splitWord(s)
+			d, err := time.ParseDuration(interval)
+			if err != nil {
+				return nil, fmt.Errorf("bad schedule '%s', expected an interval such as 1s followed by a message", s)
+			}
+			c.Schedule = append(c.Schedule, scheduleConfig{d, message})
+		}
+	}
+	if flags.Changed("device") && *device != "" {
+		c.Device.Path = *device
+	}
+	if flags.Changed("mode") {
+		c.Device.Mode = *mode
+	}
+	if flags.Changed("baud") {
+		c.Device.BaudRate = *baudRate
+	}
+	if flags.Changed("data-bits") {
+		c.Device.DataBits = *dataBits
+	}
+	if flags.Changed("stop-bits") {
+		c.Device.StopBits = *stopBits
+	}
+	if flags.Changed("min-read-size") {
+		c.Device.MinimumReadSize = *minimumReadSize
+	}
+	if flags.Changed("pass-all-port") {
+		c.Device.PassAll.Port = *passAllPort
+	}
+	if flags.Changed("j1708") {
+		c.Device.PassAll.J1708 = *passAllJ1708
+	}
+	if flags.Changed("j1587") {
+		c.Device.PassAll.J1587 = *passAllJ1587
+	}
+	if flags.Changed("can") {
+		c.Device.PassAll.CAN = *passAllCAN
+	}
+	if flags.Changed("j1939") {
+		c.Device.PassAll.J1939 = *passAllJ1939
+	}
+
+	return c, nil
+}
+
+func (c deviceConfig) serialConfig() simma.SerialConfig {
+	return simma.SerialConfig{
+		Port:            c.Path,
+		BaudRate:        c.BaudRate,
+		DataBits:        c.DataBits,
+		StopBits:        c.StopBits,
+		MinimumReadSize: c.MinimumReadSize,
+	}
+}
+
+func (c deviceConfig) passAllMode() simma.PassAllMode {
+	return simma.PassAllMode{
+		Port:  c.PassAll.Port,
+		J1708: c.PassAll.J1708,
+		J1587: c.PassAll.J1587,
+		CAN:   c.PassAll.CAN,
+		J1939: c.PassAll.J1939,
+	}
+}
+
+// simulator builds the simulator for the configured ECUs and rules.
+func (c simulateConfig) simulator(sender common.Sender) (*common.Simulator, error) {
+	s := common.NewSimulator(sender)
+
+	for _, ecu := range c.Ecus {
+		for pid, value := range ecu.Parameters {
+			err := s.SetParameter(ecu.Mid, pid, value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "failed to simulate MID '%d'", ecu.Mid)
+			}
+		}
+	}
+
+	for _, rule := range c.Rules {
+		reply, err := common.ParseMessageExpression(rule.Reply)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to parse reply '%s'", rule.Reply)
+		}
+
+		from := common.AnyMid
+		if rule.From != nil {
+			from = *rule.From
+		}
+
+		s.AddRule(common.SimulationRule{
+			From:  from,
+			Pid:   rule.Pid,
+			Reply: reply,
+		})
+	}
+
+	return s, nil
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/syncromatics/j1708-tester/pkg/simma"
+)
+
+var probeTimeout *time.Duration
+
+var devicesCmd = &cobra.Command{
+	Use:   "devices",
+	Short: "List the vehicle network adapters attached to this machine",
+	Long:  "List the vehicle network adapters attached to this machine by probing each serial port",
+	Run: func(cmd *cobra.Command, args []string) {
+		c, err := loadConfig(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		adapters, err := simma.Discover(c.Device.serialConfig(), *probeTimeout)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		if len(adapters) == 0 {
+			fmt.Println("no adapters found")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PORT\tHARDWARE\tSOFTWARE")
+		for _, a := range adapters {
+			fmt.Fprintf(w, "%s\t%d\t%d\n", a.Port, a.HardwareVersion, a.SoftwareVersion)
+		}
+		w.Flush()
+	},
+}
+
+func init() {
+	probeTimeout = devicesCmd.Flags().Duration("timeout", 2*time.Second, "How long to wait for each port to answer")
+
+	rootCmd.AddCommand(devicesCmd)
+}
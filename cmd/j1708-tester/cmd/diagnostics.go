@@ -0,0 +1,97 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/j1708-tester/pkg/common"
+)
+
+const diagnosticTimeout = 3 * time.Second
+
+const diagnosticUsage = "usage: diag faults <mid> | diag clear <mid> [pid|sid <code> <fmi>] | diag describe <mid> pid|sid <code> <fmi>"
+
+func isDiagnosticCommand(message string) bool {
+	return strings.HasPrefix(message, "diag ")
+}
+
+// runDiagnosticCommand runs a diagnostic command typed into the web client
+// and returns the text to show for it.
+func runDiagnosticCommand(diagnostics *common.DiagnosticClient, message string) (string, error) {
+	args := strings.Fields(message)[1:]
+	if len(args) < 2 {
+		return "", errors.New(diagnosticUsage)
+	}
+
+	target, err := strconv.Atoi(args[1])
+	if err != nil {
+		return "", fmt.Errorf("bad mid '%s'", args[1])
+	}
+
+	switch args[0] {
+	case "faults":
+		err = diagnostics.RequestFaults(target)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("requested diagnostic codes from MID %d", target), nil
+
+	case "clear", "describe":
+		request := common.DiagnosticRequest{
+			Target: target,
+			Action: common.ClearAllCountsAction,
+		}
+
+		if len(args) > 2 {
+			code, err := parseDiagnosticCode(args[2:])
+			if err != nil {
+				return "", err
+			}
+			request.Code = *code
+			request.Action = common.ClearCountAction
+		}
+
+		if args[0] == "describe" {
+			if len(args) == 2 {
+				return "", errors.New(diagnosticUsage)
+			}
+			request.Action = common.DescriptionAction
+		}
+
+		response, err := diagnostics.Request(request, diagnosticTimeout)
+		if err != nil {
+			return "", err
+		}
+		return response.String(), nil
+	}
+
+	return "", errors.New(diagnosticUsage)
+}
+
+func parseDiagnosticCode(args []string) (*common.DiagnosticCode, error) {
+	if len(args) != 3 {
+		return nil, errors.New(diagnosticUsage)
+	}
+
+	code, err := strconv.Atoi(args[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad code '%s'", args[1])
+	}
+
+	fmi, err := strconv.Atoi(args[2])
+	if err != nil || fmi < 0 || fmi > 15 {
+		return nil, fmt.Errorf("bad fmi '%s'", args[2])
+	}
+
+	switch args[0] {
+	case "pid":
+		return &common.DiagnosticCode{Code: code, Fmi: fmi}, nil
+	case "sid":
+		return &common.DiagnosticCode{Code: code, IsSid: true, Fmi: fmi}, nil
+	}
+
+	return nil, errors.New(diagnosticUsage)
+}
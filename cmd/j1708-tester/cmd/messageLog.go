@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/j1708-tester/pkg/common"
+)
+
+// messageLog appends each interpreted message to a file in the configured
+// format.
+type messageLog struct {
+	mtx    *sync.Mutex
+	file   *os.File
+	render func(*common.Interpretation) (string, error)
+}
+
+func openMessageLog(c logConfig) (*messageLog, error) {
+	var render func(*common.Interpretation) (string, error)
+	switch c.Format {
+	case "compact":
+		render = func(in *common.Interpretation) (string, error) {
+			return common.RenderCompact(in) + "\n", nil
+		}
+		break
+	case "json":
+		render = func(in *common.Interpretation) (string, error) {
+			b, err := common.RenderJSON(in)
+			if err != nil {
+				return "", err
+			}
+			return string(b) + "\n", nil
+		}
+		break
+	case "text":
+		render = func(in *common.Interpretation) (string, error) {
+			return common.RenderText(in), nil
+		}
+		break
+	default:
+		return nil, fmt.Errorf("unknown log format '%s', expected compact, json or text", c.Format)
+	}
+
+	f, err := os.OpenFile(c.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening log file '%s'", c.File)
+	}
+
+	return &messageLog{
+		mtx:    new(sync.Mutex),
+		file:   f,
+		render: render,
+	}, nil
+}
+
+func (l *messageLog) Write(in *common.Interpretation) error {
+	s, err := l.render(in)
+	if err != nil {
+		return errors.Wrap(err, "failed rendering message")
+	}
+
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+
+	_, err = l.file.WriteString(s)
+	return err
+}
+
+func (l *messageLog) Close() error {
+	return l.file.Close()
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/syncromatics/j1708-tester/internal/web"
+	"github.com/syncromatics/j1708-tester/pkg/common"
+	"github.com/syncromatics/j1708-tester/pkg/simma"
+)
+
+// Statuses of a request's result.
+const (
+	statusSuccess    = "success"
+	statusAcked      = "acked"
+	statusTimeout    = "timeout"
+	statusParseError = "parse-error"
+	statusError      = "error"
+)
+
+// request is a message from a web client sent as JSON, so that it gets a
+// result back carrying the same id. Plain text messages are handled the same
+// but only get a text reply when they fail.
+type request struct {
+	ID      int    `json:"id"`
+	Message string `json:"message"`
+}
+
+type result struct {
+	Type     string       `json:"type"`
+	ID       int          `json:"id"`
+	Status   string       `json:"status"`
+	Error    string       `json:"error,omitempty"`
+	Frame    common.Bytes `json:"frame,omitempty"`
+	Response string       `json:"response,omitempty"`
+}
+
+func handleClientMessage(client *web.Client, message string) {
+	envelope := strings.HasPrefix(message, "{")
+
+	req := &request{Message: message}
+	if envelope {
+		err := json.Unmarshal([]byte(message), req)
+		if err != nil {
+			reply(client, true, &result{Status: statusParseError, Error: fmt.Sprintf("invalid request: %v", err)})
+			return
+		}
+	}
+
+	if isDiagnosticCommand(req.Message) {
+		// diagnostic requests wait for their response, so they cannot block
+		// the hub
+		go func() {
+			s, err := runDiagnosticCommand(diagnostics, req.Message)
+			if err != nil {
+				reply(client, envelope, &result{ID: req.ID, Status: statusError, Error: fmt.Sprintf("diag failed: %v", err)})
+				hub.Broadcast(fmt.Sprintf("diag failed: %v\n", err))
+				return
+			}
+			reply(client, envelope, &result{ID: req.ID, Status: statusSuccess, Response: s})
+			hub.Broadcast(s + "\n")
+		}()
+		return
+	}
+
+	if isScheduleCommand(req.Message) {
+		s, err := runScheduleCommand(scheduler, req.Message)
+		if err != nil {
+			status := statusError
+			if common.IsExpressionError(err) {
+				status = statusParseError
+			}
+			reply(client, envelope, &result{ID: req.ID, Status: status, Error: fmt.Sprintf("schedule failed: %v", err)})
+			return
+		}
+		if !envelope {
+			hub.SendTo(client, s+"\n")
+		}
+		reply(client, envelope, &result{ID: req.ID, Status: statusSuccess, Response: s})
+		return
+	}
+
+	// sends wait for the adapter's ack, retrying when it times out, so they
+	// cannot block the hub either
+	go sendMessage(client, envelope, req)
+}
+
+func sendMessage(client *web.Client, envelope bool, req *request) {
+	frame, err := proxy.Send(req.Message)
+	if err != nil {
+		log.Printf("warn: %v", err)
+		reply(client, envelope, &result{ID: req.ID, Status: sendStatus(err), Error: err.Error(), Frame: frame})
+		return
+	}
+
+	hub.Broadcast(fmt.Sprintf("-->  [%s]    %v\n", time.Now().Format("3:04:05.000 PM"), frame))
+	reply(client, envelope, &result{ID: req.ID, Status: statusAcked, Frame: frame})
+}
+
+// sendStatus is the status of a send that failed with err.
+func sendStatus(err error) string {
+	if common.IsExpressionError(err) {
+		return statusParseError
+	}
+	if simma.IsAckTimeout(err) {
+		return statusTimeout
+	}
+	return statusError
+}
+
+// reply sends a result to the client that made the request.
+func reply(client *web.Client, envelope bool, r *result) {
+	s, ok := formatResult(envelope, r)
+	if !ok {
+		return
+	}
+	hub.SendTo(client, s)
+}
+
+// formatResult is a result as JSON for requests that came as JSON, or as text
+// for failed plain text requests. Successful plain text requests get no reply.
+func formatResult(envelope bool, r *result) (string, bool) {
+	if !envelope {
+		if r.Error == "" {
+			return "", false
+		}
+		return r.Error + "\n", true
+	}
+
+	r.Type = "result"
+	b, err := json.Marshal(r)
+	if err != nil {
+		log.Printf("warn: failed to encode result: %v", err)
+		return "", false
+	}
+	return string(b), true
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/j1708-tester/internal/web"
+	"github.com/syncromatics/j1708-tester/pkg/common"
+	"github.com/syncromatics/j1708-tester/pkg/simma"
+)
+
+type senderFunc func(message []byte) error
+
+func (f senderFunc) Send(message []byte) error {
+	return f(message)
+}
+
+func TestSendStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		message string
+		err     error
+		status  string
+	}{
+		{"parse error", "MID=128 PID=84 zz", nil, statusParseError},
+		{"ack timeout", "MID=128 PID=84 100", errors.Wrap(&simma.AckTimeoutError{MessageIdentifier: 1, Retries: 3}, "failed to send j1587 message"), statusTimeout},
+		{"other error", "MID=128 PID=84 100", fmt.Errorf("device is not connected"), statusError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := common.NewSendProxy(senderFunc(func([]byte) error {
+				return tt.err
+			}))
+
+			_, err := p.Send(tt.message)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if s := sendStatus(err); s != tt.status {
+				t.Errorf("expected status '%s' got '%s' for %v", tt.status, s, err)
+			}
+		})
+	}
+}
+
+func TestFormatResult(t *testing.T) {
+	s, ok := formatResult(true, &result{ID: 7, Status: statusAcked, Frame: common.Bytes{128, 84, 100}})
+	if !ok {
+		t.Fatal("expected a JSON reply")
+	}
+	r := map[string]interface{}{}
+	err := json.Unmarshal([]byte(s), &r)
+	if err != nil {
+		t.Fatalf("reply '%s' is not JSON: %v", s, err)
+	}
+	if r["type"] != "result" || r["id"] != float64(7) || r["status"] != statusAcked || fmt.Sprint(r["frame"]) != "[128 84 100]" {
+		t.Errorf("unexpected reply %v", r)
+	}
+	if _, ok := r["error"]; ok {
+		t.Errorf("expected no error in a successful reply %v", r)
+	}
+
+	s, ok = formatResult(false, &result{ID: 7, Status: statusTimeout, Error: "no ack"})
+	if !ok || s != "no ack\n" {
+		t.Errorf("expected the error as text got '%s' %v", s, ok)
+	}
+
+	s, ok = formatResult(false, &result{ID: 7, Status: statusAcked})
+	if ok {
+		t.Errorf("expected no reply to a successful plain text request got '%s'", s)
+	}
+}
+
+func TestHandleClientMessageDoesNotWaitForSend(t *testing.T) {
+	// the send blocks until the test ends, as one waiting out ack timeouts
+	release := make(chan struct{})
+	proxy = common.NewSendProxy(senderFunc(func(message []byte) error {
+		<-release
+		return nil
+	}))
+	hub = web.NewHub(handleClientMessage)
+	defer close(release)
+
+	handled := make(chan struct{})
+	go func() {
+		handleClientMessage(nil, `{"id":1,"message":"MID=128 PID=84 100"}`)
+		close(handled)
+	}()
+
+	select {
+	case <-handled:
+	case <-time.After(time.Second):
+		t.Fatal("handling the message waited for the send")
+	}
+}
@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -21,11 +22,33 @@ import (
 )
 
 var (
-	device      *string
-	port        *int
-	hub         *web.Hub
-	interpreter = &common.J1587Interpreter{}
-	addr        *string
+	configFile      *string
+	device          *string
+	mode            *string
+	port            *int
+	baudRate        *uint
+	dataBits        *uint
+	stopBits        *uint
+	minimumReadSize *uint
+	passAllPort     *int
+	passAllJ1708    *bool
+	passAllJ1587    *bool
+	passAllCAN      *bool
+	passAllJ1939    *bool
+	sourceMid       *int
+	definitions     *[]string
+	logFile         *string
+	logFormat       *string
+	messages        *messageLog
+	hub             *web.Hub
+	interpreter     = common.NewJ1587Interpreter()
+	proxy           *common.SendProxy
+	scheduler       *common.Scheduler
+	simulator       *common.Simulator
+	schedule        *[]string
+	diagnostics     *common.DiagnosticClient
+	transport       *common.Transport
+	addr            *string
 )
 
 var rootCmd = &cobra.Command{
@@ -33,23 +56,67 @@ var rootCmd = &cobra.Command{
 	Short: "j1708-tester is a tool to test vehicle networks",
 	Long:  "j1708-tester is a tool to test vehicle networks",
 	Run: func(cmd *cobra.Command, args []string) {
-		if port == nil {
-			a := 8080
-			port = &a
+		c, err := loadConfig(cmd)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, path := range c.Definitions {
+			defs, err := common.LoadDefinitions(path)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defs.Register()
 		}
 
-		a := fmt.Sprintf(":%d", *port)
+		if c.Log.File != "" {
+			messages, err = openMessageLog(c.Log)
+			if err != nil {
+				log.Fatal(err)
+			}
+			defer messages.Close()
+		}
+
+		a := fmt.Sprintf(":%d", c.Port)
 		addr = &a
 
-		if device == nil || *device == "" {
-			device = getDefaultDevice()
+		m, err := simma.ParseMode(c.Device.Mode)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		d := simma.NewDevice(*device, printMessages)
+		d := simma.NewSerialDevice(c.Device.serialConfig(), handleMessage)
+		d.SetPassAllMode(c.Device.passAllMode())
+		d.SetMode(m)
+		d.SetJ1708Handler(handleFrame)
+		d.SetStateHandler(func(s simma.ConnectionState) {
+			log.Printf("device %s %s", c.Device.Path, s)
+			hub.Broadcast(fmt.Sprintf("device %s %s\n", c.Device.Path, s))
+		})
+
+		proxy = common.NewSendProxy(d)
+
+		simulator, err = c.Simulate.simulator(d)
+		if err != nil {
+			log.Fatal(err)
+		}
 
-		proxy := common.NewSendProxy(d)
+		scheduler = common.NewScheduler(d)
+		defer scheduler.StopAll()
+		for _, s := range c.Schedule {
+			m, err := common.ParseMessageExpression(s.Message)
+			if err != nil {
+				log.Fatalf("failed to parse scheduled message '%s': %v", s.Message, err)
+			}
+			_, err = scheduler.Start(m, s.Interval)
+			if err != nil {
+				log.Fatal(err)
+			}
+		}
+		diagnostics = common.NewDiagnosticClient(c.SourceMid, d)
+		transport = common.NewTransport(c.SourceMid, d, handleMessage)
 
-		hub = web.NewHub(proxy.Send)
+		hub = web.NewHub(handleClientMessage)
 		go hub.Run()
 
 		statikFS, err := fs.New()
@@ -68,6 +135,30 @@ var rootCmd = &cobra.Command{
 			http.ServeContent(w, r, "index.html", time.Now(), f)
 		})
 
+		http.HandleFunc("/faults", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(interpreter.Faults().ActiveFaults())
+			if err != nil {
+				log.Printf("encode faults failed: %v", err)
+			}
+		})
+
+		http.HandleFunc("/schedules", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(scheduler.Jobs())
+			if err != nil {
+				log.Printf("encode schedules failed: %v", err)
+			}
+		})
+
+		http.HandleFunc("/validation", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			err := json.NewEncoder(w).Encode(interpreter.ValidationStats())
+			if err != nil {
+				log.Printf("encode validation stats failed: %v", err)
+			}
+		})
+
 		http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
 			web.ServeWs(hub, w, r)
 		})
@@ -77,8 +168,9 @@ var rootCmd = &cobra.Command{
 
 		grp.Go(d.Open(ctx))
 		grp.Go(hostWeb(ctx))
+		grp.Go(reportExpired(ctx))
 
-		log.Printf("hosting web at http://localhost:%d...\n", *port)
+		log.Printf("hosting web at http://localhost:%d...\n", c.Port)
 		log.Println("")
 		log.Println("press CTRL+C to exit.")
 
@@ -99,8 +191,26 @@ var rootCmd = &cobra.Command{
 }
 
 func init() {
-	port = rootCmd.Flags().IntP("port", "p", 8080, "The port to host the server on")
-	device = rootCmd.Flags().StringP("device", "d", "", "The vehicle network device")
+	defaults := defaultConfig()
+
+	configFile = rootCmd.PersistentFlags().StringP("config", "c", "", "A YAML config file, overridden by any flags given")
+	device = rootCmd.PersistentFlags().StringP("device", "d", "", "The vehicle network device")
+	baudRate = rootCmd.PersistentFlags().Uint("baud", defaults.Device.BaudRate, "The serial baud rate")
+	dataBits = rootCmd.PersistentFlags().Uint("data-bits", defaults.Device.DataBits, "The serial data bits")
+	stopBits = rootCmd.PersistentFlags().Uint("stop-bits", defaults.Device.StopBits, "The serial stop bits")
+	minimumReadSize = rootCmd.PersistentFlags().Uint("min-read-size", defaults.Device.MinimumReadSize, "The minimum number of bytes a serial read waits for")
+	mode = rootCmd.Flags().String("mode", defaults.Device.Mode, "How received messages are shown: j1587 parses them, j1708 shows the raw frames")
+	port = rootCmd.Flags().IntP("port", "p", defaults.Port, "The port to host the server on")
+	sourceMid = rootCmd.Flags().Int("mid", defaults.SourceMid, "The MID to send diagnostic requests from")
+	definitions = rootCmd.Flags().StringSlice("definitions", nil, "YAML or JSON files of extra MID and PID definitions")
+	schedule = rootCmd.Flags().StringArray("schedule", nil, "A message to send repeatedly, as an interval and a message such as \"1s MID=188 PID=84 100\"")
+	logFile = rootCmd.Flags().String("log-file", "", "A file to append interpreted messages to")
+	logFormat = rootCmd.Flags().String("log-format", defaults.Log.Format, "The log file format: compact, json or text")
+	passAllPort = rootCmd.Flags().Int("pass-all-port", defaults.Device.PassAll.Port, "The adapter port to enable pass all mode on")
+	passAllJ1708 = rootCmd.Flags().Bool("j1708", defaults.Device.PassAll.J1708, "Pass all j1708 messages")
+	passAllJ1587 = rootCmd.Flags().Bool("j1587", defaults.Device.PassAll.J1587, "Pass all j1587 messages")
+	passAllCAN = rootCmd.Flags().Bool("can", defaults.Device.PassAll.CAN, "Pass all CAN frames")
+	passAllJ1939 = rootCmd.Flags().Bool("j1939", defaults.Device.PassAll.J1939, "Pass all j1939 messages")
 }
 
 func Execute() {
@@ -133,12 +243,61 @@ func hostWeb(ctx context.Context) func() error {
 	}
 }
 
+// reportExpired publishes warnings for multisection parameters that time out
+// incomplete, which Interpret only reports when another message arrives.
+func reportExpired(ctx context.Context) func() error {
+	return func() error {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+
+			for _, w := range interpreter.Expire() {
+				log.Printf("warn: %s", w)
+				hub.Broadcast(fmt.Sprintf("warn: %s\n", w))
+			}
+		}
+	}
+}
+
+func handleMessage(m *common.J1587Message) {
+	diagnostics.Handle(m)
+	transport.Handle(m)
+	simulator.Handle(m)
+
+	printMessages(m)
+}
+
+func handleFrame(f *common.J1708Frame) {
+	in, err := interpreter.InterpretJ1708(f)
+	if err != nil {
+		return
+	}
+	publish(in)
+}
+
 func printMessages(m *common.J1587Message) {
-	s, err := interpreter.Interpret(m)
+	in, err := interpreter.Interpret(m)
 	if err != nil {
 		return
 	}
-	hub.Broadcast(s)
+	publish(in)
+}
+
+func publish(in *common.Interpretation) {
+	hub.Broadcast(common.RenderText(in))
+
+	if messages != nil {
+		err := messages.Write(in)
+		if err != nil {
+			log.Printf("warn: failed to log message: %v", err)
+		}
+	}
 }
 
 func getDefaultDevice() *string {
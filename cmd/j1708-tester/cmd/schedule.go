@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/j1708-tester/pkg/common"
+)
+
+const scheduleUsage = "usage: schedule <interval> <message> | schedule stop <id>|all | schedule list"
+
+func isScheduleCommand(message string) bool {
+	return message == "schedule" || strings.HasPrefix(message, "schedule ")
+}
+
+// runScheduleCommand runs a schedule command typed into the web client and
+// returns the text to show for it.
+func runScheduleCommand(scheduler *common.Scheduler, message string) (string, error) {
+	command, rest := splitWord(strings.TrimPrefix(message, "schedule"))
+
+	switch command {
+	case "list":
+		jobs := scheduler.Jobs()
+		if len(jobs) == 0 {
+			return "no scheduled messages", nil
+		}
+		lines := []string{}
+		for _, j := range jobs {
+			lines = append(lines, j.String())
+		}
+		return strings.Join(lines, "\n"), nil
+
+	case "stop":
+		if rest == "all" {
+			scheduler.StopAll()
+			return "stopped all scheduled messages", nil
+		}
+		id, err := strconv.Atoi(rest)
+		if err != nil {
+			return "", fmt.Errorf("bad id '%s'", rest)
+		}
+		err = scheduler.Stop(id)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("stopped #%d", id), nil
+
+	case "":
+		return "", errors.New(scheduleUsage)
+
+	default:
+		id, err := startSchedule(scheduler, command, rest)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("scheduled #%d every %s: %s", id, command, rest), nil
+	}
+}
+
+// startSchedule starts sending a message expression at an interval such as
+// "1s" or "500ms".
+func startSchedule(scheduler *common.Scheduler, interval string, expression string) (int, error) {
+	d, err := time.ParseDuration(interval)
+	if err != nil {
+		return 0, fmt.Errorf("bad interval '%s', expected a duration such as 1s or 500ms", interval)
+	}
+
+	m, err := common.ParseMessageExpression(expression)
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to parse message")
+	}
+
+	return scheduler.Start(m, d)
+}
+
+func splitWord(s string) (string, string) {
+	s = strings.TrimSpace(s)
+	i := strings.IndexFunc(s, func(r rune) bool { return r == ' ' || r == '\t' })
+	if i == -1 {
+		return s, ""
+	}
+	return s[:i], strings.TrimSpace(s[i:])
+}
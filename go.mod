@@ -23,4 +23,5 @@ require (
 	golang.org/x/sync v0.0.0-20181221193216-37e7f081c4d4
 	golang.org/x/sys v0.0.0-20190130150945-aca44879d564 // indirect
 	golang.org/x/tools v0.0.0-20190130190128-9bdeaddf5f7f // indirect
+	gopkg.in/yaml.v2 v2.2.2
 )
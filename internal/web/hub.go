@@ -14,7 +14,7 @@ type Hub struct {
 	clients map[*Client]bool
 
 	// Inbound messages from the clients.
-	broadcast chan []byte
+	broadcast chan *inbound
 
 	// Register requests from the clients.
 	register chan *Client
@@ -22,13 +22,19 @@ type Hub struct {
 	// Unregister requests from clients.
 	unregister chan *Client
 
-	messageHandler func(string)
+	messageHandler func(*Client, string)
 }
 
-func NewHub(messageHandler func(string)) *Hub {
+// inbound is a message from a client.
+type inbound struct {
+	client  *Client
+	message []byte
+}
+
+func NewHub(messageHandler func(*Client, string)) *Hub {
 	return &Hub{
 		mtx:            new(sync.Mutex),
-		broadcast:      make(chan []byte),
+		broadcast:      make(chan *inbound),
 		register:       make(chan *Client),
 		unregister:     make(chan *Client),
 		clients:        make(map[*Client]bool),
@@ -54,12 +60,9 @@ func (h *Hub) Run() {
 			}
 
 			h.mtx.Unlock()
-		case message := <-h.broadcast:
-			h.mtx.Lock()
-
-			h.messageHandler(string(message))
-
-			h.mtx.Unlock()
+		case in := <-h.broadcast:
+			// the handler may reply with SendTo, so the hub is not locked
+			h.messageHandler(in.client, string(in.message))
 		}
 	}
 }
@@ -79,3 +82,23 @@ func (h *Hub) Broadcast(message string) error {
 
 	return nil
 }
+
+// SendTo sends a message to one client, such as a reply to a message it sent.
+// Clients that have gone are ignored.
+func (h *Hub) SendTo(client *Client, message string) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return nil
+	}
+
+	select {
+	case client.send <- []byte(message):
+	default:
+		close(client.send)
+		delete(h.clients, client)
+	}
+
+	return nil
+}
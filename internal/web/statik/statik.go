@@ -8,6 +8,6 @@ import (
 )
 
 func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00	\xad>N\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00index.htmlUT\x05\x00\x01\xc3\x19R\\\xb4VMo\xe36\x10\xbd\xebW\xcc\xb2(\xe0`Q)A?\x0e\x8e\xa4C\xd3\x00[`\x8b\x16H\x80bQ\xf4@\x89c\x89]\x8a#\x88#)\xee\xc2\xff\xbd eY\x8a\xe3\x14\xcd\xa1\x171|\x1c\xce{\xf3\x91\xa1\xd3w?\xfdz\xf7\xf8\xe9\xb7{\xa8\xb91y\x94\xfa\x05\x8c\xb4U&\xd0\n\x0f\xa0Ty\x94\xb2f\x83\xf9]-\x19\xee\x9fd\xd3\x1aL\x93	\x8bRWv\xbae\xe0}\x8b\x99`|\xe2\xe4/9\xc8	\x15y4j\xabh\x8c\xc9\x1a\x92\n2\xd8\xf5\xb6dM\x166W\xf0%\x02\x00\x18d\x07%Y{{\xda5\xae\x82\x0c\x14\x95}\x83\x96\xe3\n\xf9\xde\xa0\xff\xf3\xc7\xfd\xcfj#\x1aW\x89\xab\xc5\xda\xd0\xbfZ\x1b\n\xd6\xc1\xfcD.\xdb\x16\xad\xfaH\xd5F36\xb3\x92\xd9\xa3\xa2\x87\xb2#c \xf3\xcec\x176\x8f\xd4B\xbe\xda\x7f@]\xd5\x0c\xdf\x04\xa84\x1a-\x9f\xa0\x9b\xdb\x93?\x7f:\xb1\xdd\xd5\xda\xa8\x89o9\xd6;\xd8\xcctk\x19\xf3\xd5\x85;\xfb/\xdc\x8b\xe3C4}\xc3\xf2jrv\xd45\xe2*&\xeb\xfa\xa2\xd1|\xb1>\xb3\xccw\xbeH\xe7\x1a;\xe4\xbe\xb3\xb0\x93\xc6\xe19\xf9\xe9b\xe3\xaax\x90\xa6\xc7\xb7\xde\xf6\x8c\xb1C\xab6\x8b\x8b\xc5\xee\x84A\x06B,\xf8K\xaf\x87c\xfd\xbd\x9a\xa9!\xff\x10\xbfc\xf1@\xe5gd\xf1\xe7Z\x96\xa7\x84\x0c,\x8ep\xb2\xd8\x88\xd1m\x93D\xc0\xfb%\x93\x86J\xe9\xf3\x14\xd7\xe4\x18\xde\x83HF'V\xe2\x82t\xb2\xa5!\x87\xcf\xd2\x8a\x03\x9f\xa7\xc1\xf7\x9c\xef\x8bu\x1b\x97\x1dJ\xc6c\xb16B\xe9a\xed=\x04\xc3\xd8\xc4\xdaZ\xec><\xfe\xf2\xd1\xe7 -\xf2;\xb2\x16'\xa6@\xad\xe24)r\xf1\xfc\xe6Y\xf7\xaf2\xffB\x7f\x83\xce\xc9\xea\x7f\x8f\xe0\x11\x9f|\xf3\xe1\xc0\xb1\x92,\xdf&\xf7\x00h\x1c\x9e\xfd\x0f\xbfM\xcd\xc5\\~\xa2\xbe\x83\xa2\xa3\xd1\xa1\x9f	\xe8\xc0\x12\x83\xeb\xdb\x96:^\xba\xc3\x9d\xa7\xf8\xa2\xdeCt\xb8\x8d\xd2d\x9a\x8b~l\xf2\xde\xe0zj\x96\xce\x89<\n\x13x\x8a\x84\x06\xecv\x86\xc6-\xd4Z)\xb4\xb7\xd1!\x8a\nR\xfbW\xcf=\xdaJ\xa5\xb4\xad\xb6p=\xed\x1b\xd9U\xda\x9e\xb6\xa3V\\o\xe1\xe6\xfa\xfa\xeb	\xa8\xc3\xdcX#\x85,?W\x1d\xf5Vm\xa1\xea\xe4>\xf0~\xe5\xa7\xec\x97\x17\xe7c\xad\x19/\x12-:\xe2\xef\xb1y\xf9=Z\x91\xd3\xbe\xa9\xb6 \x0bG\xa6\x9f\x9d1\xb5\xdb\xb5\x9d\xc1\x1d?\x03\xbaI\xf5\n)\x88\x99\x9a-|;\x03K~d\xcf4E\xe1\xe7\xdd1\x8cE\xe0,k\xed\xed<\x9aWt\xce\xa47gB\xdb\xa7W\xd2}\xb1\xa8i\x12\xba!\x8f\xd2\xe4\xf8\xe0\xfa2\xe7Q\xaa\xf4\x00Ze\xe1\x0d\xcb\xd3D\xe9!\x8f\xd2\x10\x83G\xc3\xf0\xce\x83\xdbT\xdb\xb6\x9f\x1f\xe1i\x96\x0b\x08\xb31\x13\x0fh\x95\x80\xe4\x82\xa1\xef;\x11\\\xf9'\x15\x9c\xfe\x1b3\xf1\xc3w\"\xf1J\xbcw\xbf\x1e\xa5$\xd3O\x84\x7f\x02\x00\x00\xff\xffPK\x07\x08\x1e\x16\x07'\x00\x03\x00\x003\x08\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00	\xad>N\x1e\x16\x07'\x00\x03\x00\x003\x08\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00index.htmlUT\x05\x00\x01\xc3\x19R\\PK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00A\x00\x00\x00A\x03\x00\x00\x00\x00"
+	data := "PK\x03\x04\x14\x00\x08\x00\x08\x00\x08#R]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00index.htmlUT\x05\x00\x01\xf0I\xd4j\xb4Xmo\xe3\xb8\x11\xfe\xee_1\xcbE\x0f26\x95vq\xdbC\xebX.\xae\xb9-.\xc5\xa6[\\\x16(\x0e\xdbEA\x8bc\x8b\x1b\x99\xd4\x91\x94\x9d4\xe7\xff^\x0c%\xea\xcdv\x9c\xeb\xf5\xf4!\xb1\x86\x9c\x99gf\x9e\x91\x86\x9a\xbf\xf8\xee\xc3\xd5\xc7\x1f\xff\xf1\x0er\xb7)\x16\x939\xfd\x83\x82\xabu\xcaP1\x12 \x17\x8b\xc9\xdcIW\xe0\xe2*\xe7\x0e\xde\xdd\xf3MY\xe0<\xa9e\x93\xb9\xcd\x8c,\x1d\xb8\x87\x12S\xe6\xf0\xde%_\xf8\x96\xd7R\xb6\x98\xec\xa4\x12z\x17kUh. \x85U\xa52'\xb5\x82h\n\x8f\x13\x00\x80-7\x90i\xa5.\xdb\xbb\x8d]C\nBg\xd5\x06\x95\x8b\xd7\xe8\xde\x15H?\xff\xf2p-\"\xb6\xb1k6\xedv\x17\xfa\xc9\xdd\x85\x1e\xecVx\xef\xae	\xc8\x9b\xceB\x89JHEV\x1e\xf7\x97\x13/na\xf2\x92V\xdf\xebu$\x1dn\x02\xe6\xa0)\xf4mftQ@J0b\xebo>\xea\x12\x16\xbd\xfb\xefQ\xaes\x07\xbf\xf7\xa2\xac\x90\xa8\\+jP\xd0E\xab\xb5\xb7\xab\\\x16\xa2\xf6\xd7-\xcb\x15D\xc1]\x1fFP\xed|\xf7\xb1\x9c\xf4\xdd\x19\xde\xfb_\xfb\xa3q\x7f\xc4{\x17QQ/ \xd3\x856\xe3\xf8	c?\xf9\x99A\xee\xb0\xa9V\xc4\x84\xdc\x86\xdc\xd3E\xbbc\xa9\x14\x1a\xb2\x0b)\x90\xe5\xde\xf2\n\xa2\x03/\xad\x9eu\x0f\x05\xc6~\x1d\xd2\x1aM\xa7\xbao\x7f\x8d\xeau\xd9\x0f.I\xc0\xa2\x12\xb03\xbc\xb4\xe0r\x84\x0dZ\xcb\xd7\x08R\x01\x07\x83?Uh\x1dX\x0d\xd2Y0h\xab\xc2A\xc6\x15,\x116\xdce9\nX>\x80\x14\xc3T\x91\xcd\xa8\xb1t\x90 \xa2Z\xcd\xb9W\xaf:\xbc\x0d\xe3>I\xf1\x19\xd2\x80\xa2[\xa6v\x88\xbd\xd9\xbf\xdd~\xf8{l\x9d\x91j-W\x0f\xd1\xa3\x143\x90\xe2\"\xa8\xcc\xc2\x8f\xfdt\x18j\x0b.\xe7J\x14\xf8\x83\x8f%:\xa8_\xa3\x0di\x8b\xc8\xc4R|\xee\x90\x08,\xd0\xe1x\xb5]\xb6;\xe9\xb2\x1c\"\x13[\xc7]e\xfb\xf63n\x11\x18\xcf\xeeP\xb0Y\xab\xd1\xd5\x888\x10\xb1\x97\x0c^\x01\x99\x85W\xc0\xc0\xef\x9e\x01\xc9F\x91\x9bxe\xf8\x06\xa7\x17\xc0\xd6\x06Q\xf5yE\xd7\xd2 \xbf\xebD\xb5s[e\x19Z\xfbl\xf7B+\xac\xbd7\x899\xe5\x8d\xa8jb\x83\xb6\xd4\xca\"|\xf5UP\x88\xa5\x12x\xffa\x151Kt\xa9\ndSH\xd3\x14^\xf7S\x13\xae\x1e\x92\xce\xda(\xb0\xfdSa\n\\\xf1\xaap\xcf\x8d\xaf\xbe\xa9KE\x01\xd7\xb1\x06\xee\xc2\xcf?\x03c\xd3n\xc1\xc4h\x8c6\x17\xc0\x0c\x8a3	\x1f<EN>\xbaW\xdal\xd84\xd6\xcaV\xcb\x8dtG\xdf\x07!\xbf/\xa8\x0b\xc6Y3\xe8*\xa3`\xc5\x0b\xdb\xeb\x97.GT\x98\x17\x1b\xbb\x8e\xb7\xbc\xa8\x06\xdd\xf8\x1cm\xdfr\x9dv\xe7\xa0\x95A\n\x8c]NN\xc3\xd9_\x9eI\x81A.\xfeJE\xb3>\x11Y!\xb3\xbb'\xf3p,\x0d\x1e(\x13\x92\xaf\xc1\x13\xc0\xfaB\x9eL;m\xbc\x91\x82M\x9b\xb4\x1c\x94\xed\x1c\xe8\xac@n\xfe\x9f\xa8\xbd\xc1\xdf\x18t\xd7\x81\xe7	7|\x18\x9e5y\xd3\x8d \x81\xae\x14p\xffAp\x94\x80u\xdd\x02\xb0\xa7\xe3\x97\xca\xa1\xd9\xf2\"T\xad\xed\xe0\xa1\x87\xae\x96t\x0d\xd6Fd\xdd\xff\x1a\xda\x16\xd2\xba\xdb\x06\xf7\xaf\xe7@\x9b\x012\xcb\xfe\x87\xd2:]\x064\xcf\x00C\xd5\xf5o\xe2\x93\xe1\x05@\xd7\x83\xe7\\ 2\xd5U\x8ag\x95\x94\x90\xf9\xba\xb6\xfb\xbb\xe0\xe8\x92\xe2\x89\xda\x84\xb8\x93\x04Jnl\xf3\xdan\xcaUO,\xcdT\xc2\xa1\x90\n!\xd7\x85\xb0\x17\xa0\x0d\xa8\xaa(`\xa5MX\xd1+?]\x0d\x87\xba\x9e\xd1\x88v\xf5sD\xb1\x92,vFn\xa2i\x9c\xe5\xdc|\xeb\xa2\xd7Sx\x91\xa6\xc0\x1eY\x7fs\x8fD\xe4x\x1c\x07]\xce<\x8c\x14\xa8\n4\xbb\xf97\xbb\x87\xe2\x1d\x8e\x12\xd4<R\x0d\xe5\xdc\xc4t\xac\xf0\xefOV\x07\xce\xe0\xcf``6\xf6\n\x19\x8dg\x10\x1dT\xa7\xb16\xda\xde\x7fWQ\xdc\xf5!\xe5\x13\xfb'.ouv\x87\x8e}\xee\x1b\xf2\xad\x9d\x82\xc2\x1d\xb4;\"\xb6\xb3\xb3$\x19tp\xa13Ny\x8esm\x1d\xb5k\xb2\xb3}:\x91\x1d\xdf8\xda\xe2\xa0qp\xeb\xc6\xc0\x7f\xf9t=\x9c\xb0\xbf\xffx\xf3\x9e86_.\xae\xb4RX{\xf2\xaeE<O\x96\x0bvyd(j\xcf9\xdd\xe2\xfe\x00\x7f\xf3\x8c9\x17A\x92\xc0O\x15V(\xc2C\xc9\x027Fn\x11\xbeh\xa9\xeaQ\x9aRJ\x1c\xb0\x17\x0d\xb1i\x0f\x12\xd1[\xa7\xcd\xe0\xeewy\xbe\xd3I\x8d{\x12\x81^~\xc1\xcc\x0d\xb6R\xde\x88\xf8\x90\xc2\xa7\xde\x1cK\x17n],\xb8\xe3\xb1-\x0b\xe9\"\xf6/\xc5\xa6\xf1J\x9bw<\xcb\xa3\xee)6n\x8c1}\x0f\x9ah\xe8%pj0k\xf7\xaf\xd1D~\xa8\xddu\xc1\xe1\xda~2\x12\xf8`\xe3\xb2\xb2\xf910\xfb\xd1=\x91\xdd+P	\"\xc6\xa6M\xb7\xd7=~\xd0\xe2\xa3i\xb9\xa7I\xb9\x1b\xfb\x1aSf\x0fXX\xec\x99\xfc\xe5\x9c>\xca\xe7\x1fue`i\xf4\xce\"\x9d\xc0\xd1\x82\xd2\x0elU\x96\xda\xb8\xaeC\xed\x98\xe6'\x8e\x86\xfb\xcb\xc9<\xa9\xbfW\xd0\xe7\x0c:f\xf6\xbffd\xd6\xb2\xc5\xc4\x7f\x19\xa9#\xd1[4\xabB\xeff\x90K!P]N\xf6\x93\xc9R\x8b\x87\x93\xeb$-\xb9 \xe6\xce\xe0u\xedw\xc3\xcdZ\xaa\xf6v'\x85\xcbg\xf0\xe6\xf5\xeb\xdf\xd5\x82\xdc\x1f\xdc\xfb\x92%\xcf\xee\xd6FWJ\xcc`m\xf8\x83\xf7\xfb\x92\xbe~<\x1e\xac\xefr\xe9\xf0\xa8\xa3\x0eG\xfc\x07\xdc\x1c\xfem\xd0j+\xe9\x811\x03\xbe\xb4\xba\xa8\x821\xa7\xcbY\x7f_\x81+7\x10\x98\x1auO\xb2\xd4\xce\xe9\xcd\x0cZA\x97?^9]GA\xe7\x82\x0bx\xd9\x8e\x05\x8f\xa3\x9c\x05\x9c}\xc3\xa3\x0c\x9e\x82\x1c\xfc\xbf\x19a.\xefOd\xfeh}=Bx\x1cX\xfc\x9a,\xee'\xc4\x1e\"\xcdb2O\x9a\xefe\xc4\x86\xc5d.\xe4\x16\xa4H\xfd'\xa8\xc5<\x11r\xbb\x98\xcc\xbd!\x92\xd2\x0f\xb6\xf0.\xe7R\x95U\xf8\x86VO\xaa\x0c\xfc\x98\x90\xb2[T\x82Ard#\xb5#\xf3\xa6\xe8\x8b\x18X\xf9\x1fL\xd97oY\xb3\xf7\xe6\xfa\xbbS\xfb\xc3y\xa0\xd1y\xdb:{\xf3\xa7o\x82\xfa@uY9\xa7U\xad\xdc;\xc0\x04\xb5\x1f\x90\x8b\xe6 rV\xbd\x7f\x94\x08\xfaW$\x1b\x1b\xe0\x90\x1b\\\xa5,i\xe4\xe0\xb8Y\xa3K\xd9\xbf\x97\x05Wwl\xf1m\xe6\xe8mR/\xcf\x13N\x05\xa0\xa4\xf6\x93\x1c\x18\xf5\x9cD\x87\xad!\xd9\xb8E\xf3p*\x85\xedt\x1e\xf2\xde\xdays<\x07\x9dj\xc0t\xd3U\xed\xed\x1f\xcf\xa6}8\x80\x07g\xefi~nT_\x9esx\xddV\xfc\xeb\xb3\xee\x06\x13v\xf0v\xebt\xc9\x92^\x9e\x93\x86\xe9I\xee6\xc5b\xf2\xdf\x01\x00PK\x07\x08]\xacK:\xcf\x06\x00\x00Q\x16\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x08\x00\x08#R]]\xacK:\xcf\x06\x00\x00Q\x16\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81\x00\x00\x00\x00index.htmlUT\x05\x00\x01\xf0I\xd4jPK\x05\x06\x00\x00\x00\x00\x01\x00\x01\x00A\x00\x00\x00\x10\x07\x00\x00\x00\x00"
 	fs.Register(data)
 }
@@ -0,0 +1,73 @@
+package common
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// AnyMid registers a decoder for a PID sent by any MID.
+const AnyMid = -1
+
+// Decoder decodes a parameter's data into its Value and Unit, and may rename
+// it. It is given the MID that sent the parameter, since proprietary data
+// such as PID 254 depends on the sender.
+type Decoder interface {
+	Decode(mid int, p *Parameter) error
+}
+
+type DecoderFunc func(mid int, p *Parameter) error
+
+func (f DecoderFunc) Decode(mid int, p *Parameter) error {
+	return f(mid, p)
+}
+
+type decoderKey struct {
+	mid int
+	pid int
+}
+
+var (
+	decodersMtx = new(sync.Mutex)
+	decoders    = map[decoderKey]Decoder{}
+)
+
+// RegisterDecoder registers a decoder for a PID sent by a MID, or by any MID
+// with AnyMid. A decoder for the exact MID is used before one for AnyMid. It
+// runs after the built in scaling, so it may overwrite it. Registering a nil
+// decoder removes it.
+func RegisterDecoder(mid int, pid int, d Decoder) {
+	decodersMtx.Lock()
+	defer decodersMtx.Unlock()
+
+	key := decoderKey{mid, pid}
+	if d == nil {
+		delete(decoders, key)
+		return
+	}
+	decoders[key] = d
+}
+
+func lookupDecoder(mid int, pid int) Decoder {
+	decodersMtx.Lock()
+	defer decodersMtx.Unlock()
+
+	if d, ok := decoders[decoderKey{mid, pid}]; ok {
+		return d
+	}
+	return decoders[decoderKey{AnyMid, pid}]
+}
+
+// decodeParameter runs any registered decoder for the parameter.
+func decodeParameter(mid int, p *Parameter) error {
+	d := lookupDecoder(mid, p.Pid)
+	if d == nil {
+		return nil
+	}
+
+	err := d.Decode(mid, p)
+	if err != nil {
+		return errors.Wrapf(err, "failed decoding PID '%d' from MID '%d'", p.Pid, mid)
+	}
+	return nil
+}
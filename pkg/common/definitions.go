@@ -0,0 +1,150 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Definitions are MID and PID definitions loaded from a file, so vendor
+// parameters can be decoded without rebuilding.
+type Definitions struct {
+	Mids []MidEntry `yaml:"mids" json:"mids"`
+	Pids []PidEntry `yaml:"pids" json:"pids"`
+}
+
+type MidEntry struct {
+	Mid  int    `yaml:"mid" json:"mid"`
+	Name string `yaml:"name" json:"name"`
+}
+
+// PidEntry defines a PID's name and how to decode its value. With Mid set it
+// only applies to parameters from that MID.
+type PidEntry struct {
+	Pid  int    `yaml:"pid" json:"pid"`
+	Mid  *int   `yaml:"mid" json:"mid"`
+	Name string `yaml:"name" json:"name"`
+
+	// Length is how many data bytes the value is read from, or all of them
+	// if zero.
+	Length int `yaml:"length" json:"length"`
+
+	Resolution float64        `yaml:"resolution" json:"resolution"`
+	Offset     float64        `yaml:"offset" json:"offset"`
+	Unit       string         `yaml:"unit" json:"unit"`
+	Signed     bool           `yaml:"signed" json:"signed"`
+	Text       bool           `yaml:"text" json:"text"`
+	Enum       map[int]string `yaml:"enum" json:"enum"`
+}
+
+// LoadDefinitions reads definitions from a JSON file, if its extension is
+// .json, or otherwise a YAML file.
+func LoadDefinitions(path string) (*Definitions, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed reading definitions '%s'", path)
+	}
+
+	d := &Definitions{}
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		err = json.Unmarshal(b, d)
+	} else {
+		err = yaml.UnmarshalStrict(b, d)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed parsing definitions '%s'", path)
+	}
+
+	for _, p := range d.Pids {
+		if p.Pid < 0 || p.Pid > 511 {
+			return nil, fmt.Errorf("definitions '%s': pid '%d' expected between '0' and '511'", path, p.Pid)
+		}
+	}
+
+	return d, nil
+}
+
+// Register adds the definitions to the MID and PID tables and registers a
+// decoder for each PID entry. It is safe to call while messages are being
+// interpreted, though messages already interpreted keep their old names.
+func (d *Definitions) Register() {
+	namesMtx.Lock()
+	for _, m := range d.Mids {
+		midNames[m.Mid] = m.Name
+	}
+	for _, p := range d.Pids {
+		if p.Mid == nil && p.Name != "" {
+			pidNames[p.Pid] = p.Name
+		}
+	}
+	namesMtx.Unlock()
+
+	for _, p := range d.Pids {
+		entry := p
+
+		mid := AnyMid
+		if entry.Mid != nil {
+			mid = *entry.Mid
+		}
+
+		RegisterDecoder(mid, entry.Pid, &definitionDecoder{entry})
+	}
+}
+
+type definitionDecoder struct {
+	entry PidEntry
+}
+
+func (d *definitionDecoder) Decode(mid int, p *Parameter) error {
+	e := d.entry
+
+	if e.Name != "" {
+		p.Name = e.Name
+	}
+
+	data := p.Data
+	if e.Length > 0 {
+		if len(data) < e.Length {
+			return fmt.Errorf("expected at least '%d' data bytes got '%d'", e.Length, len(data))
+		}
+		data = data[:e.Length]
+	}
+
+	if e.Text {
+		p.Value = strings.TrimRight(string(data), "\x00")
+		p.Unit = ""
+		return nil
+	}
+
+	if len(e.Enum) > 0 {
+		raw, ok := scaling{resolution: 1, signed: e.Signed}.decode(data)
+		if !ok {
+			return fmt.Errorf("enum values must be 1, 2 or 4 bytes got '%d'", len(data))
+		}
+
+		name, ok := e.Enum[int(raw)]
+		if !ok {
+			name = fmt.Sprintf("Unknown (%d)", int(raw))
+		}
+		p.Value = name
+		p.Unit = ""
+		return nil
+	}
+
+	if e.Resolution != 0 {
+		s := scaling{resolution: e.Resolution, offset: e.Offset, unit: e.Unit, signed: e.Signed}
+		v, ok := s.decode(data)
+		if !ok {
+			return fmt.Errorf("scaled values must be 1, 2 or 4 bytes got '%d'", len(data))
+		}
+		p.Value = v
+		p.Unit = e.Unit
+	}
+
+	return nil
+}
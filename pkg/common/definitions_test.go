@@ -0,0 +1,132 @@
+package common
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestLoadDefinitions(t *testing.T) {
+	door := 196
+	want := &Definitions{
+		Mids: []MidEntry{{Mid: 196, Name: "Acme Farebox"}},
+		Pids: []PidEntry{
+			{Pid: 254, Mid: &door, Name: "Farebox Door State", Length: 1, Enum: map[int]string{0: "Closed", 1: "Open"}},
+			{Pid: 200, Name: "Passenger Count", Length: 2, Resolution: 1, Unit: "pax"},
+		},
+	}
+
+	for _, path := range []string{"testdata/definitions.yaml", "testdata/definitions.json"} {
+		t.Run(path, func(t *testing.T) {
+			d, err := LoadDefinitions(path)
+			if err != nil {
+				t.Fatalf("load failed: %v", err)
+			}
+			if !reflect.DeepEqual(d, want) {
+				t.Errorf("expected %+v got %+v", want, d)
+			}
+		})
+	}
+}
+
+func TestLoadDefinitionsErrors(t *testing.T) {
+	for _, path := range []string{"testdata/missing.yaml", "testdata/unknown-field.yaml", "testdata/pid-out-of-range.yaml"} {
+		t.Run(path, func(t *testing.T) {
+			_, err := LoadDefinitions(path)
+			if err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+// registerTestDefinitions registers the test definitions, restoring the
+// tables they change when the test ends.
+func registerTestDefinitions(t *testing.T) {
+	d, err := LoadDefinitions("testdata/definitions.yaml")
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	mid := MidName(196)
+	pid := PidName(200)
+	t.Cleanup(func() {
+		namesMtx.Lock()
+		midNames[196] = mid
+		pidNames[200] = pid
+		namesMtx.Unlock()
+
+		RegisterDecoder(196, 254, nil)
+		RegisterDecoder(AnyMid, 200, nil)
+	})
+
+	d.Register()
+}
+
+func TestDefinitionsRegister(t *testing.T) {
+	registerTestDefinitions(t)
+
+	if n := MidName(196); n != "Acme Farebox" {
+		t.Errorf("expected MID 196 renamed got '%s'", n)
+	}
+	if n := PidName(200); n != "Passenger Count" {
+		t.Errorf("expected PID 200 renamed got '%s'", n)
+	}
+	if n := PidName(254); n == "Farebox Door State" {
+		t.Error("expected a MID scoped entry not to rename the PID for every MID")
+	}
+
+	tests := []struct {
+		name  string
+		mid   int
+		pid   int
+		data  []byte
+		pname string
+		value string
+	}{
+		{"MID scoped entry from its MID", 196, 254, []byte{1}, "Farebox Door State", "\"Open\""},
+		{"MID scoped entry from another MID", 197, 254, []byte{1}, PidName(254), ""},
+		{"global entry", 130, 200, []byte{0x10, 0x00}, "Passenger Count", "16 pax"},
+		{"global entry from the scoped MID", 196, 200, []byte{0x10, 0x00}, "Passenger Count", "16 pax"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParameter(tt.pid, tt.data)
+			err := decodeParameter(tt.mid, &p)
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if p.Name != tt.pname || p.FormatValue() != tt.value {
+				t.Errorf("expected '%s' '%s' got '%s' '%s'", tt.pname, tt.value, p.Name, p.FormatValue())
+			}
+		})
+	}
+
+	p := NewParameter(200, []byte{0x10})
+	err := decodeParameter(130, &p)
+	if err == nil {
+		t.Errorf("expected an error for data shorter than the entry's length got %+v", p)
+	}
+}
+
+func TestDefinitionsRegisterWhileInterpreting(t *testing.T) {
+	i := NewJ1587Interpreter()
+	m, err := ParseJ1587Message([]byte{196, 200, 0x10, 0x00})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for n := 0; n < 100; n++ {
+			i.Interpret(m)
+			LookupPidByName("Passenger Count")
+		}
+	}()
+
+	registerTestDefinitions(t)
+	wg.Wait()
+}
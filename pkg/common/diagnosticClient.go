@@ -0,0 +1,209 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSourceMid is the MID this tool sends as, Off-board Diagnostics #1.
+const DefaultSourceMid = 172
+
+// DiagnosticAction is the request or response type held in bits 8 and 7 of
+// the PID 195 and 196 diagnostic code character.
+type DiagnosticAction int
+
+const (
+	DescriptionAction DiagnosticAction = iota
+	ClearCountAction
+	ClearAllCountsAction
+)
+
+func (a DiagnosticAction) String() string {
+	switch a {
+	case DescriptionAction:
+		return "description"
+	case ClearCountAction:
+		return "clear count"
+	case ClearAllCountsAction:
+		return "clear all counts"
+	default:
+		return "reserved"
+	}
+}
+
+// DiagnosticRequest is a PID 195 diagnostic data request or clear count
+// command. Code is ignored when clearing all counts.
+type DiagnosticRequest struct {
+	Target int
+	Code   DiagnosticCode
+	Action DiagnosticAction
+}
+
+func (r DiagnosticRequest) character() byte {
+	c := byte(r.Action)<<6 | byte(r.Code.Fmi&0x0F)
+	if r.Code.IsSid {
+		c |= 0x20
+	} else if r.Code.Code > 255 {
+		c |= 0x10
+	}
+	return c
+}
+
+// Message encodes the request as a J1587 message sent from the source MID.
+func (r DiagnosticRequest) Message(source int) []byte {
+	return []byte{byte(source), 195, 3, byte(r.Target), byte(r.Code.Code), r.character()}
+}
+
+// DiagnosticResponse is a PID 196 diagnostic data or count clear response.
+type DiagnosticResponse struct {
+	Mid         int
+	Code        DiagnosticCode
+	Action      DiagnosticAction
+	Description string
+}
+
+func (r *DiagnosticResponse) String() string {
+	switch r.Action {
+	case DescriptionAction:
+		return fmt.Sprintf("MID %d %s: %q", r.Mid, r.Code.Identifier(), r.Description)
+	case ClearAllCountsAction:
+		return fmt.Sprintf("MID %d cleared all diagnostic counts", r.Mid)
+	default:
+		return fmt.Sprintf("MID %d %s: %s", r.Mid, r.Code.Identifier(), r.Action)
+	}
+}
+
+// ParseDiagnosticRequest decodes PID 195 data, not including its byte count.
+func ParseDiagnosticRequest(data []byte) (*DiagnosticRequest, error) {
+	if len(data) != 3 {
+		return nil, fmt.Errorf("diagnostic request expected length '3' got '%d'", len(data))
+	}
+
+	return &DiagnosticRequest{
+		Target: int(data[0]),
+		Code:   parseDiagnosticCharacter(data[1], data[2]),
+		Action: DiagnosticAction(data[2] >> 6),
+	}, nil
+}
+
+// ParseDiagnosticResponse decodes PID 196 data, not including its byte count.
+func ParseDiagnosticResponse(mid int, data []byte) (*DiagnosticResponse, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("diagnostic response expected length > '1' got '%d'", len(data))
+	}
+
+	return &DiagnosticResponse{
+		Mid:         mid,
+		Code:        parseDiagnosticCharacter(data[0], data[1]),
+		Action:      DiagnosticAction(data[1] >> 6),
+		Description: strings.TrimRight(string(data[2:]), "\x00"),
+	}, nil
+}
+
+type pendingDiagnostic struct {
+	request   DiagnosticRequest
+	responses chan *DiagnosticResponse
+}
+
+func (p *pendingDiagnostic) matches(r *DiagnosticResponse) bool {
+	if r.Mid != p.request.Target {
+		return false
+	}
+	if p.request.Action == ClearAllCountsAction {
+		return r.Action == ClearAllCountsAction
+	}
+	return r.Code.Code == p.request.Code.Code &&
+		r.Code.IsSid == p.request.Code.IsSid &&
+		r.Code.Fmi == p.request.Code.Fmi
+}
+
+// DiagnosticClient sends diagnostic requests and matches them with the
+// responses handed to Handle.
+type DiagnosticClient struct {
+	source int
+	sender Sender
+
+	mtx     *sync.Mutex
+	pending map[*pendingDiagnostic]bool
+}
+
+func NewDiagnosticClient(source int, sender Sender) *DiagnosticClient {
+	return &DiagnosticClient{
+		source:  source,
+		sender:  sender,
+		mtx:     new(sync.Mutex),
+		pending: map[*pendingDiagnostic]bool{},
+	}
+}
+
+// RequestFaults asks the target MID for its PID 194 diagnostic code table with
+// a component specific parameter request. The table arrives as an ordinary
+// message.
+func (c *DiagnosticClient) RequestFaults(target int) error {
+	err := c.sender.Send([]byte{byte(c.source), 128, 194, byte(target)})
+	if err != nil {
+		return errors.Wrap(err, "failed to send diagnostic table request")
+	}
+	return nil
+}
+
+// Request sends the request and waits for the target's response.
+func (c *DiagnosticClient) Request(request DiagnosticRequest, timeout time.Duration) (*DiagnosticResponse, error) {
+	p := &pendingDiagnostic{
+		request:   request,
+		responses: make(chan *DiagnosticResponse, 1),
+	}
+
+	c.mtx.Lock()
+	c.pending[p] = true
+	c.mtx.Unlock()
+
+	defer func() {
+		c.mtx.Lock()
+		delete(c.pending, p)
+		c.mtx.Unlock()
+	}()
+
+	err := c.sender.Send(request.Message(c.source))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to send diagnostic request")
+	}
+
+	select {
+	case r := <-p.responses:
+		return r, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("no diagnostic response from MID '%d' after %v", request.Target, timeout)
+	}
+}
+
+// Handle passes any PID 196 responses in the message to waiting requests.
+func (c *DiagnosticClient) Handle(message *J1587Message) {
+	parameters, _ := message.Parameters()
+	for _, p := range parameters {
+		if p.Pid != 196 {
+			continue
+		}
+
+		r, err := ParseDiagnosticResponse(message.Mid, p.Data)
+		if err != nil {
+			continue
+		}
+
+		c.mtx.Lock()
+		for pending := range c.pending {
+			if !pending.matches(r) {
+				continue
+			}
+			select {
+			case pending.responses <- r:
+			default:
+			}
+		}
+		c.mtx.Unlock()
+	}
+}
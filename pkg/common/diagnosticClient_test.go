@@ -0,0 +1,161 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDiagnosticRequestMessage(t *testing.T) {
+	tests := []struct {
+		name    string
+		request DiagnosticRequest
+		message []byte
+	}{
+		{"pid description", DiagnosticRequest{128, DiagnosticCode{Code: 100, Fmi: 3}, DescriptionAction}, []byte{172, 195, 3, 128, 100, 0x03}},
+		{"sid description", DiagnosticRequest{128, DiagnosticCode{Code: 5, IsSid: true, Fmi: 2}, DescriptionAction}, []byte{172, 195, 3, 128, 5, 0x22}},
+		{"page 2 pid", DiagnosticRequest{128, DiagnosticCode{Code: 300, Fmi: 4}, DescriptionAction}, []byte{172, 195, 3, 128, 44, 0x14}},
+		{"clear count", DiagnosticRequest{136, DiagnosticCode{Code: 5, IsSid: true, Fmi: 2}, ClearCountAction}, []byte{172, 195, 3, 136, 5, 0x62}},
+		{"clear all counts", DiagnosticRequest{Target: 136, Action: ClearAllCountsAction}, []byte{172, 195, 3, 136, 0, 0x80}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := tt.request.Message(172)
+			if !bytes.Equal(m, tt.message) {
+				t.Fatalf("expected %v got %v", tt.message, m)
+			}
+
+			r, err := ParseDiagnosticRequest(m[3:])
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if r.Target != tt.request.Target || r.Action != tt.request.Action ||
+				r.Code.Code != tt.request.Code.Code || r.Code.IsSid != tt.request.Code.IsSid || r.Code.Fmi != tt.request.Code.Fmi {
+				t.Errorf("parsed %+v expected %+v", r, tt.request)
+			}
+		})
+	}
+
+	if _, err := ParseDiagnosticRequest([]byte{128, 100}); err == nil {
+		t.Error("expected an error for a short request")
+	}
+}
+
+func TestParseDiagnosticResponse(t *testing.T) {
+	r, err := ParseDiagnosticResponse(128, []byte{100, 0x03, 'L', 'o', 'w', 0, 0})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if r.Mid != 128 || r.Action != DescriptionAction || r.Code.Code != 100 || r.Code.Fmi != 3 || r.Description != "Low" {
+		t.Errorf("unexpected response %+v", r)
+	}
+
+	r, err = ParseDiagnosticResponse(136, []byte{0, 0x80})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if r.Action != ClearAllCountsAction || r.String() != "MID 136 cleared all diagnostic counts" {
+		t.Errorf("unexpected response %+v", r)
+	}
+
+	if _, err := ParseDiagnosticResponse(128, []byte{100}); err == nil {
+		t.Error("expected an error for a short response")
+	}
+}
+
+func TestDiagnosticClientRequestFaults(t *testing.T) {
+	sender := newFakeSender()
+	c := NewDiagnosticClient(172, sender)
+
+	err := c.RequestFaults(128)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if m := sender.next(t); !bytes.Equal(m, []byte{172, 128, 194, 128}) {
+		t.Errorf("unexpected request %v", m)
+	}
+}
+
+func TestDiagnosticClientRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   DiagnosticRequest
+		responses [][]byte
+		answered  bool
+	}{
+		{
+			"description",
+			DiagnosticRequest{128, DiagnosticCode{Code: 100, Fmi: 3}, DescriptionAction},
+			[][]byte{{128, 196, 5, 100, 0x03, 'L', 'o', 'w'}},
+			true,
+		},
+		{
+			"clear all counts",
+			DiagnosticRequest{Target: 136, Action: ClearAllCountsAction},
+			[][]byte{{136, 196, 2, 0, 0x80}},
+			true,
+		},
+		{
+			"response after others",
+			DiagnosticRequest{128, DiagnosticCode{Code: 100, Fmi: 3}, DescriptionAction},
+			[][]byte{{130, 196, 2, 100, 0x03}, {128, 196, 2, 100, 0x04}, {128, 196, 2, 100, 0x23}, {128, 196, 2, 100, 0x03}},
+			true,
+		},
+		{
+			"another MID's response",
+			DiagnosticRequest{128, DiagnosticCode{Code: 100, Fmi: 3}, DescriptionAction},
+			[][]byte{{130, 196, 2, 100, 0x03}},
+			false,
+		},
+		{
+			"no response",
+			DiagnosticRequest{Target: 136, Action: ClearAllCountsAction},
+			[][]byte{},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := newFakeSender()
+			c := NewDiagnosticClient(172, sender)
+
+			type answer struct {
+				response *DiagnosticResponse
+				err      error
+			}
+			answers := make(chan answer, 1)
+			go func() {
+				r, err := c.Request(tt.request, 100*time.Millisecond)
+				answers <- answer{r, err}
+			}()
+
+			if m := sender.next(t); !bytes.Equal(m, tt.request.Message(172)) {
+				t.Fatalf("expected request %v got %v", tt.request.Message(172), m)
+			}
+
+			for _, raw := range tt.responses {
+				m, err := ParseJ1587Message(raw)
+				if err != nil {
+					t.Fatalf("parse failed: %v", err)
+				}
+				c.Handle(m)
+			}
+
+			a := <-answers
+			if !tt.answered {
+				if a.err == nil {
+					t.Errorf("expected a timeout got %+v", a.response)
+				}
+				return
+			}
+			if a.err != nil {
+				t.Fatalf("request failed: %v", a.err)
+			}
+			if a.response.Mid != tt.request.Target || a.response.Action != tt.request.Action {
+				t.Errorf("unexpected response %+v", a.response)
+			}
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var fmiDescriptions = map[int]string{
+	0:  "Data valid but above normal operational range",
+	1:  "Data valid but below normal operational range",
+	2:  "Data erratic, intermittent, or incorrect",
+	3:  "Voltage above normal or shorted high",
+	4:  "Voltage below normal or shorted low",
+	5:  "Current below normal or open circuit",
+	6:  "Current above normal or grounded circuit",
+	7:  "Mechanical system not responding properly",
+	8:  "Abnormal frequency, pulse width, or period",
+	9:  "Abnormal update rate",
+	10: "Abnormal rate of change",
+	11: "Failure mode not identifiable",
+	12: "Bad intelligent device or component",
+	13: "Out of calibration",
+	14: "Special instructions",
+	15: "Reserved",
+}
+
+// sidNames are the subsystem identifiers common to every MID. Other SIDs are
+// specific to the transmitting MID.
+var sidNames = map[int]string{
+	151: "System Diagnostic Code #1",
+	152: "System Diagnostic Code #2",
+	153: "System Diagnostic Code #3",
+	154: "System Diagnostic Code #4",
+	155: "System Diagnostic Code #5",
+	240: "Program Memory",
+	241: "Set Up Memory",
+	242: "Power Down Data",
+	243: "Real Time Clock",
+	248: "Proprietary Data Link",
+	249: "SAE J1922 Data Link",
+	250: "SAE J1708 (J1587) Data Link",
+	251: "Power Supply",
+	252: "Calibration Module",
+	253: "Calibration Memory",
+	254: "Controller #1",
+}
+
+func FmiDescription(fmi int) string {
+	d, ok := fmiDescriptions[fmi]
+	if !ok {
+		return "Unknown"
+	}
+	return d
+}
+
+func SidName(sid int) string {
+	d, ok := sidNames[sid]
+	if !ok {
+		return "Unknown"
+	}
+	return d
+}
+
+// DiagnosticCode is one entry of a PID 194 diagnostic code table.
+type DiagnosticCode struct {
+	// Code is a SID, or a PID with page 2 PIDs numbered 256-511.
+	Code   int
+	IsSid  bool
+	Fmi    int
+	Active bool
+
+	// Count is the occurrence count, or -1 if it was not sent.
+	Count int
+}
+
+func (c DiagnosticCode) Name() string {
+	if c.IsSid {
+		return SidName(c.Code)
+	}
+	return PidName(c.Code)
+}
+
+// Identifier describes the code and failure mode without its status.
+func (c DiagnosticCode) Identifier() string {
+	kind := "PID"
+	if c.IsSid {
+		kind = "SID"
+	}
+
+	return fmt.Sprintf("%s %d %s, FMI %d %s", kind, c.Code, c.Name(), c.Fmi, FmiDescription(c.Fmi))
+}
+
+func (c DiagnosticCode) String() string {
+	status := "inactive"
+	if c.Active {
+		status = "active"
+	}
+
+	s := fmt.Sprintf("%s, %s", c.Identifier(), status)
+	if c.Count >= 0 {
+		s += fmt.Sprintf(", count %d", c.Count)
+	}
+
+	return s
+}
+
+func (c DiagnosticCode) key() int {
+	k := c.Code<<4 | c.Fmi
+	if c.IsSid {
+		k |= 1 << 16
+	}
+	return k
+}
+
+func parseDiagnosticCharacter(code byte, character byte) DiagnosticCode {
+	c := DiagnosticCode{
+		Code:   int(code),
+		IsSid:  character&0x20 != 0,
+		Fmi:    int(character & 0x0F),
+		Active: character&0x40 == 0,
+		Count:  -1,
+	}
+
+	if character&0x10 != 0 && !c.IsSid {
+		c.Code += 256
+	}
+
+	return c
+}
+
+// ParseDiagnosticCodes decodes PID 194 data, not including its byte count.
+func ParseDiagnosticCodes(data []byte) ([]DiagnosticCode, error) {
+	codes := []DiagnosticCode{}
+
+	i := 0
+	for i < len(data) {
+		if i+2 > len(data) {
+			return codes, fmt.Errorf("diagnostic code at byte '%d' expected at least '2' bytes got '%d'", i, len(data)-i)
+		}
+
+		c := parseDiagnosticCharacter(data[i], data[i+1])
+		includesCount := data[i+1]&0x80 != 0
+		i += 2
+
+		if includesCount {
+			if i == len(data) {
+				return codes, fmt.Errorf("diagnostic code at byte '%d' is missing its occurrence count", i-2)
+			}
+			c.Count = int(data[i])
+			i++
+		}
+
+		codes = append(codes, c)
+	}
+
+	return codes, nil
+}
+
+// FaultTable keeps the latest diagnostic code table broadcast by each MID.
+type FaultTable struct {
+	mtx   *sync.Mutex
+	codes map[int][]DiagnosticCode
+}
+
+func NewFaultTable() *FaultTable {
+	return &FaultTable{
+		mtx:   new(sync.Mutex),
+		codes: map[int][]DiagnosticCode{},
+	}
+}
+
+// Update replaces the MID's codes, since PID 194 always carries the
+// transmitter's whole table.
+func (t *FaultTable) Update(mid int, codes []DiagnosticCode) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	sorted := append([]DiagnosticCode{}, codes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].key() < sorted[j].key()
+	})
+
+	t.codes[mid] = sorted
+}
+
+// Active returns the MID's active codes.
+func (t *FaultTable) Active(mid int) []DiagnosticCode {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	active := []DiagnosticCode{}
+	for _, c := range t.codes[mid] {
+		if c.Active {
+			active = append(active, c)
+		}
+	}
+
+	return active
+}
+
+// ActiveFaults returns the active codes of every MID that has any.
+func (t *FaultTable) ActiveFaults() map[int][]DiagnosticCode {
+	t.mtx.Lock()
+	mids := []int{}
+	for mid := range t.codes {
+		mids = append(mids, mid)
+	}
+	t.mtx.Unlock()
+
+	faults := map[int][]DiagnosticCode{}
+	for _, mid := range mids {
+		active := t.Active(mid)
+		if len(active) > 0 {
+			faults[mid] = active
+		}
+	}
+
+	return faults
+}
@@ -0,0 +1,361 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+// pidAliases are short names accepted for PIDs whose full names are long.
+var pidAliases = map[string]int{
+	"request":            0,
+	"componentidrequest": 128,
+	"componentrequest":   128,
+	"multisection":       192,
+	"diagnosticcodes":    194,
+	"diagnosticrequest":  195,
+	"diagnosticresponse": 196,
+	"connectioncontrol":  197,
+	"connectiondata":     198,
+	"softwareid":         234,
+	"vin":                237,
+	"componentid":        243,
+}
+
+// normalizeName reduces a name to lower case letters and digits so
+// "Component-specific Parameter Request" matches "componentspecificparameterrequest".
+func normalizeName(name string) string {
+	sb := new(strings.Builder)
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return sb.String()
+}
+
+// lookupName finds the lowest identifier whose normalized name matches.
+func lookupName(names map[int]string, name string) (int, bool) {
+	namesMtx.RLock()
+	defer namesMtx.RUnlock()
+
+	n := normalizeName(name)
+
+	found := -1
+	for id, v := range names {
+		if normalizeName(v) == n && (found == -1 || id < found) {
+			found = id
+		}
+	}
+	return found, found != -1
+}
+
+// LookupMidByName returns the MID with a name matching, ignoring case, spaces
+// and punctuation.
+func LookupMidByName(name string) (int, bool) {
+	return lookupName(midNames, name)
+}
+
+// LookupPidByName returns the PID with a name or alias matching, ignoring
+// case, spaces and punctuation. Page 1 is preferred where both pages share
+// a name.
+func LookupPidByName(name string) (int, bool) {
+	if pid, ok := pidAliases[normalizeName(name)]; ok {
+		return pid, true
+	}
+	return lookupName(pidNames, name)
+}
+
+// ExpressionError is an error in a message expression, at the token given.
+type ExpressionError struct {
+	Token   string
+	Message string
+}
+
+func (e *ExpressionError) Error() string {
+	if e.Token == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("'%s': %s", e.Token, e.Message)
+}
+
+// IsExpressionError reports whether err, or the error it wraps, is an
+// ExpressionError.
+func IsExpressionError(err error) bool {
+	_, ok := errors.Cause(err).(*ExpressionError)
+	return ok
+}
+
+// ParseMessageExpression builds a J1587 message from an expression of
+// whitespace separated tokens:
+//
+//	MID=<mid>          the message identifier, first
+//	PID=<pid>          starts a parameter, its data follows
+//	196, 0xC4, C4h     a byte in decimal or hex
+//	"text"             ASCII bytes
+//
+// MIDs and PIDs are numbers or names, such as MID=Farebox or
+// PID=ComponentIdRequest. PIDs 256-511 are written behind the page 2
+// extension. The count byte of variable length PIDs is added, and a single
+// number given to a two byte PID is written little endian.
+//
+// An expression without MID= is a list of bytes sent as is, such as
+// "172 128 194 196".
+func ParseMessageExpression(expression string) ([]byte, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, &ExpressionError{Message: "message is empty"}
+	}
+
+	if !hasKey(tokens[0], "mid") {
+		m := []byte{}
+		for _, t := range tokens {
+			if hasKey(t, "mid") || hasKey(t, "pid") {
+				return nil, &ExpressionError{t, "MID= must come first"}
+			}
+			d, err := parseData(t)
+			if err != nil {
+				return nil, err
+			}
+			if d.wide {
+				return nil, &ExpressionError{t, "number expected between '0' and '255'"}
+			}
+			m = append(m, d.bytes...)
+		}
+		return m, nil
+	}
+
+	mid, err := parseIdentifier(tokens[0], LookupMidByName)
+	if err != nil {
+		return nil, err
+	}
+	if mid < 0 || mid > 255 {
+		return nil, &ExpressionError{tokens[0], "MID expected between '0' and '255'"}
+	}
+
+	m := []byte{byte(mid)}
+
+	i := 1
+	for i < len(tokens) {
+		if !hasKey(tokens[i], "pid") {
+			return nil, &ExpressionError{tokens[i], "expected PID="}
+		}
+
+		pidToken := tokens[i]
+		pid, err := parseIdentifier(pidToken, LookupPidByName)
+		if err != nil {
+			return nil, err
+		}
+		if pid < 0 || pid > 511 || pid%256 == 255 {
+			return nil, &ExpressionError{pidToken, "PID expected between '0' and '511' and not an extension"}
+		}
+		i++
+
+		data := []*dataToken{}
+		for i < len(tokens) && !hasKey(tokens[i], "pid") {
+			if hasKey(tokens[i], "mid") {
+				return nil, &ExpressionError{tokens[i], "MID= must come first"}
+			}
+			d, err := parseData(tokens[i])
+			if err != nil {
+				return nil, err
+			}
+			data = append(data, d)
+			i++
+		}
+
+		p, err := encodeParameter(pid, data)
+		if err != nil {
+			return nil, &ExpressionError{pidToken, err.Error()}
+		}
+		m = append(m, p...)
+	}
+
+	return m, nil
+}
+
+// EncodeParameter writes a PID and its data given as the data tokens of a
+// message expression, such as "100", "2052" or "\"VLU-1000\"", with the page 2
+// extension and count byte added as ParseMessageExpression does.
+func EncodeParameter(pid int, value string) ([]byte, error) {
+	if pid < 0 || pid > 511 || pid%256 == 255 {
+		return nil, fmt.Errorf("pid '%d' expected between '0' and '511' and not an extension", pid)
+	}
+
+	tokens, err := tokenize(value)
+	if err != nil {
+		return nil, err
+	}
+
+	data := []*dataToken{}
+	for _, t := range tokens {
+		d, err := parseData(t)
+		if err != nil {
+			return nil, err
+		}
+		data = append(data, d)
+	}
+
+	return encodeParameter(pid, data)
+}
+
+func encodeParameter(pid int, tokens []*dataToken) ([]byte, error) {
+	p := []byte{}
+	if pid > 255 {
+		p = append(p, 255)
+	}
+	p = append(p, byte(pid))
+
+	class := PidLengthClass(pid)
+
+	data := []byte{}
+	for _, t := range tokens {
+		if t.wide && (class != DoubleByte || len(tokens) != 1) {
+			return nil, fmt.Errorf("numbers over '255' are only accepted as the value of a two byte PID")
+		}
+		data = append(data, t.bytes...)
+	}
+
+	switch class {
+	case SingleByte:
+		if len(data) != 1 {
+			return nil, fmt.Errorf("expected '1' data byte got '%d'", len(data))
+		}
+		break
+	case DoubleByte:
+		if len(data) != 2 {
+			return nil, fmt.Errorf("expected '2' data bytes got '%d'", len(data))
+		}
+		break
+	case VariableLength:
+		if len(data) > 255 {
+			return nil, fmt.Errorf("expected at most '255' data bytes got '%d'", len(data))
+		}
+		p = append(p, byte(len(data)))
+		break
+	}
+
+	return append(p, data...), nil
+}
+
+// tokenize splits on whitespace outside of double quotes.
+func tokenize(expression string) ([]string, error) {
+	tokens := []string{}
+
+	sb := new(strings.Builder)
+	quoted := false
+	for _, r := range expression {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			sb.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if sb.Len() > 0 {
+				tokens = append(tokens, sb.String())
+				sb.Reset()
+			}
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	if quoted {
+		return nil, &ExpressionError{sb.String(), "missing closing quote"}
+	}
+	if sb.Len() > 0 {
+		tokens = append(tokens, sb.String())
+	}
+
+	return tokens, nil
+}
+
+func hasKey(token string, key string) bool {
+	i := strings.Index(token, "=")
+	return i != -1 && strings.EqualFold(token[:i], key)
+}
+
+// parseIdentifier parses the value of a MID= or PID= token as a number or a
+// name.
+func parseIdentifier(token string, lookup func(string) (int, bool)) (int, error) {
+	value := unquote(token[strings.Index(token, "=")+1:])
+	if value == "" {
+		return 0, &ExpressionError{token, "missing value"}
+	}
+
+	if n, ok := parseNumber(value); ok {
+		return n, nil
+	}
+
+	id, ok := lookup(value)
+	if !ok {
+		return 0, &ExpressionError{token, fmt.Sprintf("unknown name '%s'", value)}
+	}
+	return id, nil
+}
+
+// dataToken is the bytes of a data token. Wide numbers, 256 to 65535, are
+// two bytes little endian and only accepted as the value of a two byte PID.
+type dataToken struct {
+	bytes []byte
+	wide  bool
+}
+
+// parseData parses a quoted string to its bytes or a number.
+func parseData(token string) (*dataToken, error) {
+	if strings.HasPrefix(token, "\"") {
+		s := unquote(token)
+		for _, r := range s {
+			if r > unicode.MaxASCII {
+				return nil, &ExpressionError{token, "strings must be ASCII"}
+			}
+		}
+		return &dataToken{bytes: []byte(s)}, nil
+	}
+
+	n, ok := parseNumber(token)
+	if !ok {
+		return nil, &ExpressionError{token, "expected a number or a quoted string"}
+	}
+
+	switch {
+	case n >= 0 && n <= 255:
+		return &dataToken{bytes: []byte{byte(n)}}, nil
+	case n > 255 && n <= 0xFFFF:
+		return &dataToken{bytes: []byte{byte(n), byte(n >> 8)}, wide: true}, nil
+	default:
+		return nil, &ExpressionError{token, "number expected between '0' and '65535'"}
+	}
+}
+
+// parseNumber parses decimal, 0x prefixed hex or h suffixed hex.
+func parseNumber(s string) (int, bool) {
+	base := 10
+	switch {
+	case strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0X"):
+		s = s[2:]
+		base = 16
+		break
+	case strings.HasSuffix(s, "h") || strings.HasSuffix(s, "H"):
+		s = s[:len(s)-1]
+		base = 16
+		break
+	}
+
+	n, err := strconv.ParseInt(s, base, 32)
+	if err != nil {
+		return 0, false
+	}
+	return int(n), true
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && strings.HasPrefix(s, "\"") && strings.HasSuffix(s, "\"") {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
@@ -0,0 +1,140 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestParseMessageExpression(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		message    []byte
+	}{
+		{"raw bytes", "172 128 194 196", []byte{172, 128, 194, 196}},
+		{"raw hex bytes", "0xAC 80h 0XC2 c4H", []byte{172, 128, 194, 196}},
+		{"decimal", "MID=128 PID=84 100", []byte{128, 84, 100}},
+		{"0x hex", "MID=0x80 PID=0x54 0x64", []byte{128, 84, 100}},
+		{"h suffix hex", "MID=80h PID=54h 64h", []byte{128, 84, 100}},
+		{"lower case keys", "mid=128 pid=84 100", []byte{128, 84, 100}},
+		{"names", "MID=Transmission PID=RoadSpeed 100", []byte{130, 84, 100}},
+		{"names folding case and punctuation", "MID=engine#1 PID=road-speed 100", []byte{128, 84, 100}},
+		{"quoted name with spaces", "MID=\"Engine #1\" PID=\"Road Speed\" 100", []byte{128, 84, 100}},
+		{"alias", "MID=128 PID=request 84", []byte{128, 0, 84}},
+		{"alias folding", "MID=172 PID=Component-ID-Request 243 128", []byte{172, 128, 243, 128}},
+		{"page 2", "MID=128 PID=259 1", []byte{128, 255, 3, 1}},
+		{"page 2 first", "MID=128 PID=256 84", []byte{128, 255, 0, 84}},
+		{"count byte", "MID=128 PID=243 1 2 3", []byte{128, 243, 3, 1, 2, 3}},
+		{"quoted string with spaces", "MID=128 PID=243 \"VLU 1000\"", []byte{128, 243, 8, 'V', 'L', 'U', ' ', '1', '0', '0', '0'}},
+		{"empty variable length", "MID=128 PID=243", []byte{128, 243, 0}},
+		{"wide value little endian", "MID=128 PID=190 2052", []byte{128, 190, 4, 8}},
+		{"two byte pid as bytes", "MID=128 PID=190 4 8", []byte{128, 190, 4, 8}},
+		{"several pids", "MID=128 PID=84 100 PID=190 0x804", []byte{128, 84, 100, 190, 4, 8}},
+		{"mid only", "MID=128", []byte{128}},
+		{"extra whitespace", "  MID=128\tPID=84   100 \n", []byte{128, 84, 100}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseMessageExpression(tt.expression)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if !bytes.Equal(m, tt.message) {
+				t.Errorf("'%s' expected %v got %v", tt.expression, tt.message, m)
+			}
+		})
+	}
+}
+
+func TestParseMessageExpressionErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{"empty", "   "},
+		{"bad token", "MID=128 PID=84 zz"},
+		{"bad raw byte", "172 12x"},
+		{"raw byte over 255", "172 300"},
+		{"number over 65535", "MID=128 PID=190 70000"},
+		{"negative number", "MID=128 PID=84 -1"},
+		{"mid over 255", "MID=256 PID=84 100"},
+		{"pid over 511", "MID=128 PID=512 1"},
+		{"page extension pid", "MID=128 PID=255 1"},
+		{"page 2 extension pid", "MID=128 PID=511 1"},
+		{"unknown mid name", "MID=Nonexistent PID=84 100"},
+		{"unknown pid name", "MID=128 PID=Nonexistent 100"},
+		{"missing mid value", "MID= PID=84 100"},
+		{"mid not first", "172 MID=128"},
+		{"second mid", "MID=128 PID=84 100 MID=130"},
+		{"data before pid", "MID=128 100"},
+		{"single byte pid with two bytes", "MID=128 PID=84 100 101"},
+		{"single byte pid with wide value", "MID=128 PID=84 300"},
+		{"two byte pid with one byte", "MID=128 PID=190 4"},
+		{"wide value with other bytes", "MID=128 PID=190 2052 1"},
+		{"wide value in variable length", "MID=128 PID=243 2052"},
+		{"missing closing quote", "MID=128 PID=243 \"VLU 1000"},
+		{"non ascii string", "MID=128 PID=243 \"café\""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMessageExpression(tt.expression)
+			if err == nil {
+				t.Fatalf("'%s' expected an error", tt.expression)
+			}
+			if !IsExpressionError(err) {
+				t.Errorf("'%s' expected an ExpressionError got %T: %v", tt.expression, err, err)
+			}
+		})
+	}
+}
+
+func TestEncodeParameter(t *testing.T) {
+	tests := []struct {
+		name  string
+		pid   int
+		value string
+		bytes []byte
+	}{
+		{"single byte", 84, "100", []byte{84, 100}},
+		{"wide value", 190, "2052", []byte{190, 4, 8}},
+		{"count byte", 243, "\"VLU-1000\"", []byte{243, 8, 'V', 'L', 'U', '-', '1', '0', '0', '0'}},
+		{"page 2", 259, "1", []byte{255, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := EncodeParameter(tt.pid, tt.value)
+			if err != nil {
+				t.Fatalf("encode failed: %v", err)
+			}
+			if !bytes.Equal(p, tt.bytes) {
+				t.Errorf("PID %d '%s' expected %v got %v", tt.pid, tt.value, tt.bytes, p)
+			}
+		})
+	}
+
+	_, err := EncodeParameter(255, "1")
+	if err == nil {
+		t.Error("expected an error encoding the page extension PID")
+	}
+}
+
+func TestLookupByName(t *testing.T) {
+	if mid, ok := LookupMidByName("ENGINE #1"); !ok || mid != 128 {
+		t.Errorf("expected MID '128' got '%d' %v", mid, ok)
+	}
+	if pid, ok := LookupPidByName("engine speed"); !ok || pid != 190 {
+		t.Errorf("expected PID '190' got '%d' %v", pid, ok)
+	}
+	if pid, ok := LookupPidByName("Request Parameter"); !ok || pid != 0 {
+		t.Errorf("expected page 1 PID '0' preferred over page 2 got '%d' %v", pid, ok)
+	}
+	if pid, ok := LookupPidByName("VIN"); !ok || pid != 237 {
+		t.Errorf("expected the alias for PID '237' got '%d' %v", pid, ok)
+	}
+	if _, ok := LookupMidByName("nonexistent"); ok {
+		t.Error("expected no MID named 'nonexistent'")
+	}
+}
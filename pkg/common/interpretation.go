@@ -0,0 +1,204 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Bytes marshals to JSON as an array of numbers rather than base64, to match
+// how messages are typed and displayed.
+type Bytes []byte
+
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	n := make([]int, len(b))
+	for i, v := range b {
+		n[i] = int(v)
+	}
+	return json.Marshal(n)
+}
+
+// Protocols of an Interpretation.
+const (
+	J1587Protocol = "j1587"
+	J1708Protocol = "j1708"
+)
+
+// Interpretation is the decoded form of one message.
+type Interpretation struct {
+	Protocol string    `json:"protocol"`
+	Time     time.Time `json:"time"`
+
+	// Delta is the time since the previous message interpreted, in
+	// nanoseconds in JSON.
+	Delta    time.Duration `json:"delta"`
+	Sequence uint64        `json:"sequence"`
+
+	Mid        int                        `json:"mid"`
+	MidName    string                     `json:"midName"`
+	Raw        Bytes                      `json:"raw"`
+	Violations []Violation                `json:"violations"`
+	Parameters []*ParameterInterpretation `json:"parameters"`
+	Warnings   []string                   `json:"warnings"`
+
+	// Checksum is the frame's checksum as received, or nil, null in JSON, when
+	// the adapter removed it before passing the frame on.
+	Checksum *int `json:"checksum"`
+
+	// There is no priority: j1708 frames carry none, only the bus idle time
+	// before them, which the adapter does not report.
+
+	// Data is only set for j1708 frames, whose data is not parsed into
+	// parameters.
+	Data Bytes `json:"data,omitempty"`
+}
+
+// Detail is a decoded field of a parameter that is not its value, such as the
+// receiver of a request or one diagnostic code of a table.
+type Detail struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+type ParameterInterpretation struct {
+	Parameter
+	Length   string   `json:"length"`
+	Details  []Detail `json:"details"`
+	Warnings []string `json:"warnings"`
+
+	// Children are parameters completed by this one, such as the last
+	// section of a multisection parameter.
+	Children []*ParameterInterpretation `json:"children,omitempty"`
+}
+
+func (pi *ParameterInterpretation) detail(label string, value string) {
+	pi.Details = append(pi.Details, Detail{label, value})
+}
+
+func (pi *ParameterInterpretation) warn(err error) {
+	pi.Warnings = append(pi.Warnings, err.Error())
+}
+
+// RenderText renders the interpretation as the multi-line, semicolon
+// commented text shown in the web log.
+func RenderText(in *Interpretation) string {
+	sb := new(strings.Builder)
+
+	sb.WriteString(fmt.Sprintf("<--  [%s] %s #%d    ", in.Time.Format("3:04:05.000 PM"), formatDelta(in.Delta), in.Sequence))
+	sb.WriteString(fmt.Sprintf("%v\n", []byte(in.Raw)))
+
+	sb.WriteString("\n")
+
+	sb.WriteString(fmt.Sprintf(";    MID %d : %s\n", in.Mid, in.MidName))
+
+	sb.WriteString(fmt.Sprintf(";    Checksum: %s\n", formatChecksum(in.Checksum)))
+
+	for _, v := range in.Violations {
+		sb.WriteString(fmt.Sprintf(";    invalid: %s\n", v))
+	}
+
+	if in.Protocol == J1708Protocol {
+		sb.WriteString(fmt.Sprintf(";    Data: %v\n", []byte(in.Data)))
+	}
+
+	for _, p := range in.Parameters {
+		renderParameterText(sb, p)
+	}
+
+	for _, w := range in.Warnings {
+		sb.WriteString(fmt.Sprintf(";    warn: %s\n", w))
+	}
+
+	sb.WriteString("----\n")
+
+	return sb.String()
+}
+
+func renderParameterText(sb *strings.Builder, p *ParameterInterpretation) {
+	sb.WriteString(fmt.Sprintf(";    PID %d : %s (%s)\n", p.Pid, p.Name, p.Length))
+
+	if v := p.FormatValue(); v != "" {
+		sb.WriteString(fmt.Sprintf(";      Value: %s\n", v))
+	}
+
+	for _, d := range p.Details {
+		sb.WriteString(fmt.Sprintf(";      %s: %s\n", d.Label, d.Value))
+	}
+
+	if len(p.Details) == 0 {
+		sb.WriteString(fmt.Sprintf(";      Data: %v\n", []byte(p.Data)))
+	}
+
+	for _, w := range p.Warnings {
+		sb.WriteString(fmt.Sprintf(";      warn: %s\n", w))
+	}
+
+	for _, c := range p.Children {
+		sb.WriteString(";    Completed:\n")
+		renderParameterText(sb, c)
+	}
+}
+
+func RenderJSON(in *Interpretation) ([]byte, error) {
+	return json.Marshal(in)
+}
+
+// RenderCompact renders the interpretation on one line, for log files.
+func RenderCompact(in *Interpretation) string {
+	parts := []string{
+		fmt.Sprintf("%s %s #%d MID %d %s", in.Time.Format("2006-01-02T15:04:05.000"), formatDelta(in.Delta), in.Sequence, in.Mid, in.MidName),
+	}
+
+	for _, v := range in.Violations {
+		parts = append(parts, "invalid: "+v.String())
+	}
+
+	if in.Protocol == J1708Protocol {
+		parts = append(parts, fmt.Sprintf("j1708 %v checksum %s", []byte(in.Data), formatChecksum(in.Checksum)))
+	}
+
+	var add func(p *ParameterInterpretation)
+	add = func(p *ParameterInterpretation) {
+		s := fmt.Sprintf("PID %d %s", p.Pid, p.Name)
+		if v := p.FormatValue(); v != "" {
+			s += "=" + v
+		} else if len(p.Details) == 0 {
+			s += fmt.Sprintf("=%v", []byte(p.Data))
+		}
+		for _, d := range p.Details {
+			s += fmt.Sprintf(" [%s: %s]", d.Label, d.Value)
+		}
+		for _, w := range p.Warnings {
+			s += " warn: " + w
+		}
+		parts = append(parts, s)
+
+		for _, c := range p.Children {
+			add(c)
+		}
+	}
+
+	for _, p := range in.Parameters {
+		add(p)
+	}
+
+	for _, w := range in.Warnings {
+		parts = append(parts, "warn: "+w)
+	}
+
+	return strings.Join(parts, " | ")
+}
+
+// formatChecksum formats a checksum that may not be known.
+func formatChecksum(c *int) string {
+	if c == nil {
+		return "unavailable, removed by the adapter"
+	}
+	return fmt.Sprintf("%d", *c)
+}
+
+// formatDelta formats the gap between messages in milliseconds.
+func formatDelta(d time.Duration) string {
+	return fmt.Sprintf("+%.3fms", float64(d)/float64(time.Millisecond))
+}
@@ -2,73 +2,286 @@ package common
 
 import (
 	"fmt"
-	"strings"
+	"sync"
 	"time"
 )
 
+// J1587Interpreter decodes messages. The zero value is ready to use.
 type J1587Interpreter struct {
+	mtx           sync.Mutex
+	faults        *FaultTable
+	multisections *MultisectionAssembler
+	last          time.Time
+	validation    ValidationStats
 }
 
-func (i *J1587Interpreter) Interpret(message *J1587Message) (string, error) {
-	sb := new(strings.Builder)
+func NewJ1587Interpreter() *J1587Interpreter {
+	return &J1587Interpreter{}
+}
 
-	sb.WriteString(fmt.Sprintf("<--  [%s]    ", time.Now().Format("3:04:05 PM")))
-	sb.WriteString(fmt.Sprintf("%v\n", message.Raw))
+// lazyInit creates the interpreter's state on first use, with i.mtx held.
+func (i *J1587Interpreter) lazyInit() {
+	if i.faults != nil {
+		return
+	}
 
-	sb.WriteString("\n")
+	i.faults = NewFaultTable()
+	i.multisections = NewMultisectionAssembler(DefaultMultisectionTimeout)
+	i.validation.Violations = map[string]int{}
+}
 
-	midType := i.getMidDefinition(message.Mid)
-	sb.WriteString(fmt.Sprintf(";    MID %d : %s\n", message.Mid, midType))
+// Faults is the active fault table kept from the PID 194 messages interpreted.
+func (i *J1587Interpreter) Faults() *FaultTable {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
 
-	pidType := i.getPidDefinition(message.Pid)
-	sb.WriteString(fmt.Sprintf(";    PID %d : %s\n", message.Pid, pidType))
+	i.lazyInit()
+	return i.faults
+}
 
-	switch message.Pid {
-	case 128:
-		i.interpretComponentIdRequest(sb, message.Data)
-		break
+// ValidationStats counts the frames interpreted and the rules they broke.
+func (i *J1587Interpreter) ValidationStats() ValidationStats {
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	i.lazyInit()
+
+	s := i.validation
+	s.Violations = map[string]int{}
+	for rule, count := range i.validation.Violations {
+		s.Violations[rule] = count
 	}
+	return s
+}
 
-	sb.WriteString("----\n")
+// Expire returns a warning for each multisection parameter whose sections
+// did not all arrive in time. Interpret reports them too, but only when
+// another message arrives, so call Expire periodically on a quiet bus.
+func (i *J1587Interpreter) Expire() []string {
+	i.mtx.Lock()
+	i.lazyInit()
+	multisections := i.multisections
+	i.mtx.Unlock()
 
-	return sb.String(), nil
+	return multisections.Expire()
 }
 
-func (i *J1587Interpreter) interpretComponentIdRequest(sb *strings.Builder, message []byte) {
-	pidInfo := i.getPidDefinition(int(message[0]))
+// Interpret decodes a message into an Interpretation, which can then be
+// rendered with RenderText, RenderJSON or RenderCompact.
+func (i *J1587Interpreter) Interpret(message *J1587Message) (*Interpretation, error) {
+	frame := NewJ1708Frame(message.Mid, nil)
+	if len(message.Raw) > 0 {
+		frame = NewJ1708Frame(message.Mid, message.Raw[1:])
+	}
+	frame.Received = message.Received
+	frame.Sequence = message.Sequence
 
-	sb.WriteString(fmt.Sprintf(";    Requested Parameter %d:\n", message[0]))
-	sb.WriteString(fmt.Sprintf(";      %s\n", pidInfo))
+	in := i.newInterpretation(J1587Protocol, frame, ValidateJ1587Frame(frame))
 
-	rd := i.getMidDefinition(int(message[1]))
-	sb.WriteString(fmt.Sprintf(";    Receiver MID: %d - %s\n", message[1], rd))
+	parameters, err := message.Parameters()
+	for _, p := range parameters {
+		decodeErr := decodeParameter(message.Mid, &p)
+
+		pi := i.interpretParameter(message.Mid, p)
+		if decodeErr != nil {
+			pi.warn(decodeErr)
+		}
+		in.Parameters = append(in.Parameters, pi)
+	}
+	if err != nil {
+		in.Warnings = append(in.Warnings, err.Error())
+	}
+
+	in.Warnings = append(in.Warnings, i.multisections.Expire()...)
+
+	return in, nil
 }
 
-func (i *J1587Interpreter) getMidDefinition(mid int) string {
-	d := "Unknown"
+// InterpretJ1708 describes a raw j1708 frame without parsing its data as
+// j1587 parameters.
+func (i *J1587Interpreter) InterpretJ1708(frame *J1708Frame) (*Interpretation, error) {
+	in := i.newInterpretation(J1708Protocol, frame, ValidateJ1708Frame(frame))
+	in.Data = frame.Data
+
+	return in, nil
+}
+
+func (i *J1587Interpreter) newInterpretation(protocol string, frame *J1708Frame, violations []Violation) *Interpretation {
+	received := frame.Received
+	if received.IsZero() {
+		received = time.Now()
+	}
 
-	switch mid {
-	case 188:
-		d = "Vehicle Logic Control Unit"
+	i.mtx.Lock()
+	i.lazyInit()
+
+	delta := time.Duration(0)
+	if !i.last.IsZero() {
+		delta = received.Sub(i.last)
+	}
+	i.last = received
+
+	i.validation.Frames++
+	if len(violations) > 0 {
+		i.validation.Invalid++
+	}
+	for _, v := range violations {
+		i.validation.Violations[v.Rule]++
+	}
+	i.mtx.Unlock()
+
+	var checksum *int
+	if frame.Checksum >= 0 {
+		c := frame.Checksum
+		checksum = &c
+	}
+
+	return &Interpretation{
+		Protocol:   protocol,
+		Time:       received,
+		Delta:      delta,
+		Sequence:   frame.Sequence,
+		Mid:        frame.Mid,
+		MidName:    MidName(frame.Mid),
+		Raw:        frame.Body(),
+		Checksum:   checksum,
+		Violations: violations,
+		Parameters: []*ParameterInterpretation{},
+		Warnings:   []string{},
+	}
+}
+
+func (i *J1587Interpreter) interpretParameter(mid int, p Parameter) *ParameterInterpretation {
+	pidType, _ := LookupPid(p.Pid)
+
+	pi := &ParameterInterpretation{
+		Parameter: p,
+		Length:    pidType.Length.String(),
+		Details:   []Detail{},
+		Warnings:  []string{},
+	}
+
+	switch p.Pid {
+	case 128:
+		i.interpretComponentIdRequest(pi)
+		break
+	case 192, 448:
+		i.interpretMultisection(pi, mid)
+		break
+	case 194:
+		i.interpretDiagnosticCodes(pi, mid)
+		break
+	case 195:
+		i.interpretDiagnosticRequest(pi)
 		break
 	case 196:
-		d = "Farebox"
+		i.interpretDiagnosticResponse(pi, mid)
+		break
+	case 197:
+		i.interpretTransportControl(pi)
+		break
+	case 198:
+		i.interpretTransportSegment(pi)
 		break
 	}
 
-	return d
+	return pi
 }
 
-func (i *J1587Interpreter) getPidDefinition(pid int) string {
-	d := "Unknown"
+func (i *J1587Interpreter) interpretComponentIdRequest(pi *ParameterInterpretation) {
+	if len(pi.Data) < 2 {
+		pi.warn(fmt.Errorf("component id request expected length '2' got '%d'", len(pi.Data)))
+		return
+	}
 
-	switch pid {
-	case 128:
-		d = "Component Identification Request"
-	case 234:
-		d = "Software Identification"
-		break
+	pi.detail("Requested Parameter", fmt.Sprintf("%d - %s", pi.Data[0], PidName(int(pi.Data[0]))))
+	pi.detail("Receiver MID", fmt.Sprintf("%d - %s", pi.Data[1], MidName(int(pi.Data[1]))))
+}
+
+func (i *J1587Interpreter) interpretDiagnosticCodes(pi *ParameterInterpretation, mid int) {
+	codes, err := ParseDiagnosticCodes(pi.Data)
+	for _, c := range codes {
+		pi.detail("Diagnostic Code", c.String())
+	}
+	if err != nil {
+		pi.warn(err)
+		return
+	}
+
+	i.faults.Update(mid, codes)
+
+	active := i.faults.Active(mid)
+	pi.detail("Active Faults", fmt.Sprintf("%d", len(active)))
+	for _, c := range active {
+		pi.detail("Active Fault", c.String())
+	}
+}
+
+func (i *J1587Interpreter) interpretDiagnosticRequest(pi *ParameterInterpretation) {
+	r, err := ParseDiagnosticRequest(pi.Data)
+	if err != nil {
+		pi.warn(err)
+		return
+	}
+
+	pi.detail("Receiver MID", fmt.Sprintf("%d - %s", r.Target, MidName(r.Target)))
+	pi.detail("Request", r.Action.String())
+	if r.Action != ClearAllCountsAction {
+		pi.detail("Diagnostic Code", r.Code.Identifier())
+	}
+}
+
+func (i *J1587Interpreter) interpretDiagnosticResponse(pi *ParameterInterpretation, mid int) {
+	r, err := ParseDiagnosticResponse(mid, pi.Data)
+	if err != nil {
+		pi.warn(err)
+		return
+	}
+
+	pi.detail("Response", r.String())
+}
+
+func (i *J1587Interpreter) interpretTransportControl(pi *ParameterInterpretation) {
+	c, err := ParseTransportControl(pi.Data)
+	if err != nil {
+		pi.warn(err)
+		return
+	}
+
+	pi.detail("Connection", c.String())
+}
+
+func (i *J1587Interpreter) interpretTransportSegment(pi *ParameterInterpretation) {
+	s, err := ParseTransportSegment(pi.Data)
+	if err != nil {
+		pi.warn(err)
+		return
+	}
+
+	pi.detail("Segment", fmt.Sprintf("%d to MID %d: %v", s.Segment, s.Receiver, s.Data))
+}
+
+func (i *J1587Interpreter) interpretMultisection(pi *ParameterInterpretation, mid int) {
+	h, section, err := ParseMultisection(pi.Data)
+	if err != nil {
+		pi.warn(err)
+		return
+	}
+
+	// page 2 multisection parameters carry page 2 PIDs
+	if pi.Pid > 255 {
+		h.Pid += 256
+	}
+
+	pi.detail("Section", fmt.Sprintf("%d of %d for PID %d: %v", h.Section+1, h.LastSection+1, h.Pid, section))
+
+	p, err := i.multisections.Add(mid, h, section)
+	if err != nil {
+		pi.warn(err)
+	}
+	if p == nil {
+		return
 	}
 
-	return d
+	pi.Children = append(pi.Children, i.interpretParameter(mid, *p))
 }
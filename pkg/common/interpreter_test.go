@@ -0,0 +1,95 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestJ1587InterpreterZeroValue(t *testing.T) {
+	i := &J1587Interpreter{}
+
+	m, err := ParseJ1587Message([]byte{196, 194, 2, 131, 5})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	in, err := i.Interpret(m)
+	if err != nil {
+		t.Fatalf("interpret failed: %v", err)
+	}
+	if len(in.Parameters) != 1 || in.Parameters[0].Pid != 194 {
+		t.Fatalf("unexpected parameters %+v", in.Parameters)
+	}
+
+	if active := i.Faults().Active(196); len(active) != 1 {
+		t.Errorf("expected one active fault got %v", active)
+	}
+	if s := i.ValidationStats(); s.Frames != 1 {
+		t.Errorf("expected one frame validated got %+v", s)
+	}
+}
+
+func TestJ1587InterpreterDecodesOnce(t *testing.T) {
+	calls := 0
+	RegisterDecoder(196, 233, DecoderFunc(func(mid int, p *Parameter) error {
+		calls++
+		if len(p.Data) < 2 {
+			return fmt.Errorf("expected 2 bytes got %d", len(p.Data))
+		}
+		p.Name = "Vendor Status"
+		p.Value = float64(p.Data[1])
+		return nil
+	}))
+	defer RegisterDecoder(196, 233, nil)
+
+	m, err := ParseJ1587Message([]byte{196, 233, 2, 1, 7, 233, 1, 1})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	in, err := NewJ1587Interpreter().Interpret(m)
+	if err != nil {
+		t.Fatalf("interpret failed: %v", err)
+	}
+
+	if calls != 2 {
+		t.Errorf("expected the decoder to run once per parameter got '%d' calls", calls)
+	}
+	if len(in.Parameters) != 2 {
+		t.Fatalf("unexpected parameters %+v", in.Parameters)
+	}
+	if p := in.Parameters[0]; p.Name != "Vendor Status" || p.Value != float64(7) {
+		t.Errorf("expected the decoded parameter got %+v", p.Parameter)
+	}
+	if p := in.Parameters[1]; len(p.Warnings) != 1 {
+		t.Errorf("expected the decoder error as a warning got %v", p.Warnings)
+	}
+}
+
+func TestJ1587InterpreterChecksum(t *testing.T) {
+	i := NewJ1587Interpreter()
+
+	in, err := i.InterpretJ1708(NewJ1708Frame(128, []byte{84, 100}))
+	if err != nil {
+		t.Fatalf("interpret failed: %v", err)
+	}
+	if in.Checksum != nil {
+		t.Errorf("expected no checksum for a frame without one got '%d'", *in.Checksum)
+	}
+	if text := RenderText(in); !strings.Contains(text, "Checksum: unavailable") {
+		t.Errorf("expected the checksum shown as unavailable got\n%s", text)
+	}
+
+	f, err := ParseJ1708Frame([]byte{128, 84, 100, 200})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	in, err = i.InterpretJ1708(f)
+	if err != nil {
+		t.Fatalf("interpret failed: %v", err)
+	}
+	if in.Checksum == nil || *in.Checksum != 200 {
+		t.Errorf("expected the received checksum '200' got %v", in.Checksum)
+	}
+}
@@ -0,0 +1,145 @@
+package common
+
+import (
+	"fmt"
+	"time"
+)
+
+// J1708Frame is a frame as it appears on the bus, a MID followed by any data
+// and a checksum, without any J1587 meaning given to the data.
+type J1708Frame struct {
+	Mid  int
+	Data []byte
+
+	// Checksum is the frame's trailing byte as received, or -1 if it is not
+	// known, such as when the adapter checked and removed it.
+	Checksum int
+
+	// Received and Sequence are as for J1587Message.
+	Received time.Time
+	Sequence uint64
+}
+
+// J1708Checksum is the two's complement of the sum of the bytes, so that a
+// frame including its checksum sums to zero.
+func J1708Checksum(b []byte) byte {
+	sum := byte(0)
+	for _, v := range b {
+		sum += v
+	}
+	return -sum
+}
+
+// NewJ1708Frame builds a frame from its MID and data, with its checksum not
+// known.
+func NewJ1708Frame(mid int, data []byte) *J1708Frame {
+	return &J1708Frame{
+		Mid:      mid,
+		Data:     data,
+		Checksum: -1,
+	}
+}
+
+// ParseJ1708Frame splits the bytes of a frame, its MID, data and trailing
+// checksum. The checksum is kept as received and is not checked.
+func ParseJ1708Frame(raw []byte) (*J1708Frame, error) {
+	if len(raw) < 2 {
+		return nil, fmt.Errorf("j1708 frame expected length > '1' got '%d'", len(raw))
+	}
+
+	return &J1708Frame{
+		Mid:      int(raw[0]),
+		Data:     raw[1 : len(raw)-1],
+		Checksum: int(raw[len(raw)-1]),
+	}, nil
+}
+
+// Body is the MID and data, the frame without its checksum.
+func (f *J1708Frame) Body() []byte {
+	return append([]byte{byte(f.Mid)}, f.Data...)
+}
+
+// Bytes is the frame as sent on the bus, including its checksum, which is
+// calculated if it is not known.
+func (f *J1708Frame) Bytes() []byte {
+	b := f.Body()
+	if f.Checksum < 0 {
+		return append(b, J1708Checksum(b))
+	}
+	return append(b, byte(f.Checksum))
+}
+
+// J1708MaxLength is the longest frame allowed on the bus, including its MID
+// and checksum.
+const J1708MaxLength = 21
+
+// Rules a frame can break.
+const (
+	LengthRule   = "length"
+	ChecksumRule = "checksum"
+	MidRule      = "mid"
+)
+
+// Violation is a frame breaking one of the j1708 or j1587 rules.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Rule, v.Message)
+}
+
+// ValidateJ1708Frame checks a frame's length, its checksum if it is known and
+// that its MID is assigned, by j1708 (0-68), j1922 (69-86) or j1587
+// (128-255). Frames received from the adapter have no checksum to check, as
+// the adapter checks and removes it, dropping frames that fail. A frame's
+// priority is not checked, as it is not sent: it is only the bus idle time a
+// transmitter waits for, which the adapter does not pass on.
+func ValidateJ1708Frame(f *J1708Frame) []Violation {
+	violations := validateFrame(f)
+
+	if f.Mid >= 87 && f.Mid <= 127 {
+		violations = append(violations, Violation{MidRule, fmt.Sprintf("MID '%d' is not assigned", f.Mid)})
+	}
+
+	return violations
+}
+
+// ValidateJ1587Frame checks a frame as ValidateJ1708Frame does, except that
+// its MID must be one assigned to j1587.
+func ValidateJ1587Frame(f *J1708Frame) []Violation {
+	violations := validateFrame(f)
+
+	if f.Mid >= 0 && f.Mid < 128 {
+		violations = append(violations, Violation{MidRule, fmt.Sprintf("MID '%d' is not a j1587 MID", f.Mid)})
+	}
+
+	return violations
+}
+
+// validateFrame checks the rules shared by j1708 and j1587 frames.
+func validateFrame(f *J1708Frame) []Violation {
+	violations := []Violation{}
+
+	if l := len(f.Data) + 2; l > J1708MaxLength {
+		violations = append(violations, Violation{LengthRule, fmt.Sprintf("frame length '%d' is over the '%d' byte maximum", l, J1708MaxLength)})
+	}
+
+	if c := int(J1708Checksum(f.Body())); f.Checksum >= 0 && c != f.Checksum {
+		violations = append(violations, Violation{ChecksumRule, fmt.Sprintf("checksum expected '%d' got '%d'", c, f.Checksum)})
+	}
+
+	if f.Mid < 0 || f.Mid > 255 {
+		violations = append(violations, Violation{MidRule, fmt.Sprintf("MID '%d' expected between '0' and '255'", f.Mid)})
+	}
+
+	return violations
+}
+
+// ValidationStats counts the frames validated and the rules they broke.
+type ValidationStats struct {
+	Frames     int            `json:"frames"`
+	Invalid    int            `json:"invalid"`
+	Violations map[string]int `json:"violations"`
+}
@@ -0,0 +1,42 @@
+package common
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateFrames(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame *J1708Frame
+		j1708 []string
+		j1587 []string
+	}{
+		{"j1587 MID", NewJ1708Frame(128, []byte{84, 100}), []string{}, []string{}},
+		{"j1708 MID", NewJ1708Frame(10, []byte{1}), []string{}, []string{"mid: MID '10' is not a j1587 MID"}},
+		{"j1922 MID", NewJ1708Frame(80, []byte{1}), []string{}, []string{"mid: MID '80' is not a j1587 MID"}},
+		{"unassigned MID", NewJ1708Frame(100, []byte{1}), []string{"mid: MID '100' is not assigned"}, []string{"mid: MID '100' is not a j1587 MID"}},
+		{"too long", NewJ1708Frame(128, make([]byte, 20)), []string{"length: frame length '22' is over the '21' byte maximum"}, []string{"length: frame length '22' is over the '21' byte maximum"}},
+		{"good checksum", &J1708Frame{Mid: 128, Data: []byte{84, 100}, Checksum: 200}, []string{}, []string{}},
+		{"bad checksum", &J1708Frame{Mid: 128, Data: []byte{84, 100}, Checksum: 201}, []string{"checksum: checksum expected '200' got '201'"}, []string{"checksum: checksum expected '200' got '201'"}},
+	}
+
+	messages := func(violations []Violation) []string {
+		m := []string{}
+		for _, v := range violations {
+			m = append(m, v.String())
+		}
+		return m
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := messages(ValidateJ1708Frame(tt.frame)); !reflect.DeepEqual(got, tt.j1708) {
+				t.Errorf("j1708 violations expected %v got %v", tt.j1708, got)
+			}
+			if got := messages(ValidateJ1587Frame(tt.frame)); !reflect.DeepEqual(got, tt.j1587) {
+				t.Errorf("j1587 violations expected %v got %v", tt.j1587, got)
+			}
+		})
+	}
+}
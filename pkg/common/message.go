@@ -1,8 +1,80 @@
 package common
 
+import (
+	"fmt"
+	"time"
+)
+
 type J1587Message struct {
 	Mid  int
 	Pid  int
 	Data []byte
 	Raw  []byte
+
+	// Received is when the adapter's frame carrying the message was
+	// validated, including a monotonic reading for measuring gaps between
+	// messages. It is zero for messages built locally.
+	Received time.Time
+
+	// Sequence numbers the bus messages received on a connection to the
+	// adapter, starting at 1. Frames of the adapter's own, such as acks and
+	// stats, are not counted. It is zero for messages built locally.
+	Sequence uint64
+}
+
+// NewJ1587Message builds a message from its MID, first PID and data. PIDs
+// 256-511 are written to Raw behind the page 2 extension PID 255.
+func NewJ1587Message(mid int, pid int, data []byte) *J1587Message {
+	raw := []byte{byte(mid)}
+	if pid > 255 {
+		raw = append(raw, 255, byte(pid-256))
+	} else {
+		raw = append(raw, byte(pid))
+	}
+	raw = append(raw, data...)
+
+	return &J1587Message{
+		Mid:  mid,
+		Pid:  pid,
+		Data: data,
+		Raw:  raw,
+	}
+}
+
+// ParseJ1587Message splits a message's raw bytes into its MID, first PID and
+// the data following that PID. A leading page 2 extension is folded into the
+// PID as 256-511.
+func ParseJ1587Message(raw []byte) (*J1587Message, error) {
+	if len(raw) < 2 {
+		return nil, fmt.Errorf("j1587 message expected length > '1' got '%d'", len(raw))
+	}
+
+	m := &J1587Message{
+		Mid: int(raw[0]),
+		Raw: raw,
+	}
+
+	if raw[1] == 255 {
+		if len(raw) < 3 {
+			return nil, fmt.Errorf("page 2 j1587 message expected length > '2' got '%d'", len(raw))
+		}
+		m.Pid = 256 + int(raw[2])
+		m.Data = raw[3:]
+	} else {
+		m.Pid = int(raw[1])
+		m.Data = raw[2:]
+	}
+
+	return m, nil
+}
+
+// Parameters parses every parameter in the message, not only the first, with
+// the built-in scaling applied. Registered decoders are run by the
+// interpreter.
+func (m *J1587Message) Parameters() ([]Parameter, error) {
+	if len(m.Raw) < 1 {
+		return nil, fmt.Errorf("message has no MID")
+	}
+
+	return ParseParameters(m.Raw[1:])
 }
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJ1587MessageRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		pid  int
+		data []byte
+		raw  []byte
+	}{
+		{"page 1", 0, []byte{84}, []byte{128, 0, 84}},
+		{"data link escape", 254, []byte{1, 2, 3}, []byte{128, 254, 1, 2, 3}},
+		{"page 2 first", 256, []byte{84}, []byte{128, 255, 0, 84}},
+		{"page 2", 259, []byte{1}, []byte{128, 255, 3, 1}},
+		{"page 2 data link escape", 510, []byte{1, 2}, []byte{128, 255, 254, 1, 2}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewJ1587Message(128, tt.pid, tt.data)
+			if !bytes.Equal(m.Raw, tt.raw) {
+				t.Fatalf("raw %v expected %v", m.Raw, tt.raw)
+			}
+
+			p, err := ParseJ1587Message(m.Raw)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			if p.Mid != 128 || p.Pid != tt.pid || !bytes.Equal(p.Data, tt.data) || !bytes.Equal(p.Raw, tt.raw) {
+				t.Errorf("parsed %+v expected MID 128 PID %d data %v", p, tt.pid, tt.data)
+			}
+		})
+	}
+}
+
+func TestParseJ1587MessageTooShort(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []byte
+	}{
+		{"empty", []byte{}},
+		{"mid only", []byte{128}},
+		{"page 2 extension only", []byte{128, 255}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseJ1587Message(tt.raw)
+			if err == nil {
+				t.Errorf("expected an error parsing %v", tt.raw)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package common
+
+import "sync"
+
+// namesMtx guards midNames and pidNames, which definitions can add to while
+// messages are being interpreted.
+var namesMtx = new(sync.RWMutex)
+
+// midNames are the SAE J1587 message identifiers. MIDs below 128 are
+// assigned by SAE J1708 (0-68) and SAE J1922 (69-86) or not assigned
+// (87-127), and are not listed.
+var midNames = map[int]string{
+	128: "Engine #1",
+	129: "Turbocharger",
+	130: "Transmission",
+	131: "Power Takeoff",
+	132: "Axle, Power Unit",
+	133: "Axle, Trailer #1",
+	134: "Axle, Trailer #2",
+	135: "Axle, Trailer #3",
+	136: "Brakes, Power Unit",
+	137: "Brakes, Trailer #1",
+	138: "Brakes, Trailer #2",
+	139: "Brakes, Trailer #3",
+	140: "Instrument Cluster",
+	141: "Trip Recorder",
+	142: "Vehicle Management System",
+	143: "Fuel System",
+	144: "Cruise Control",
+	145: "Road Speed Indicator",
+	146: "Cab Climate Control",
+	147: "Cargo Refrigeration/Heating, Trailer #1",
+	148: "Cargo Refrigeration/Heating, Trailer #2",
+	149: "Cargo Refrigeration/Heating, Trailer #3",
+	150: "Suspension, Power Unit",
+	151: "Suspension, Trailer #1",
+	152: "Suspension, Trailer #2",
+	153: "Suspension, Trailer #3",
+	154: "Diagnostic Systems, Power Unit",
+	155: "Diagnostic Systems, Trailer #1",
+	156: "Diagnostic Systems, Trailer #2",
+	157: "Diagnostic Systems, Trailer #3",
+	158: "Electrical Charging System",
+	159: "Proximity Detector, Front",
+	160: "Proximity Detector, Rear",
+	161: "Aerodynamic Control Unit",
+	162: "Vehicle Navigation Unit",
+	163: "Vehicle Security",
+	164: "Multiplex",
+	165: "Communication Unit, Ground",
+	166: "Tires, Power Unit",
+	167: "Tires, Trailer #1",
+	168: "Tires, Trailer #2",
+	169: "Tires, Trailer #3",
+	170: "Electrical",
+	171: "Driver Information Center",
+	172: "Off-board Diagnostics #1",
+	173: "Engine Retarder",
+	174: "Cranking/Starting System",
+	175: "Engine #2",
+	176: "Transmission, Additional",
+	177: "Particulate Trap System",
+	178: "Vehicle Sensors to Data Converter",
+	179: "Data Logging Computer",
+	180: "Off-board Diagnostics #2",
+	181: "Communication Unit, Satellite",
+	182: "Off-board Programming Station",
+	183: "Engine #3",
+	184: "Engine #4",
+	185: "Engine #5",
+	186: "Engine #6",
+	187: "Vehicle Control Head Unit",
+	188: "Vehicle Logic Control Unit",
+	189: "Vehicle Head Signs",
+	190: "Refrigerant Management Protection and Diagnostics",
+	191: "Vehicle Location Unit, Differential Correction",
+	192: "Front Door Status Unit",
+	193: "Middle Door Status Unit",
+	194: "Rear Door Status Unit",
+	195: "Annunciator Unit",
+	196: "Farebox",
+	197: "Passenger Counter Unit",
+	198: "Schedule Adherence Unit",
+	199: "Route Adherence Unit",
+	200: "Environment Monitor Unit",
+	201: "Vehicle Status Points Monitor Unit",
+	202: "High Speed Communications Unit",
+	203: "Mobile Data Terminal Unit",
+	204: "Vehicle Proximity, Right Side",
+	205: "Vehicle Proximity, Left Side",
+	206: "Base Unit (Radio Gateway to Fixed End)",
+	207: "Bridge from SAE J1708 Drivetrain Link",
+	208: "Maintenance Printer",
+	209: "Vehicle Turntable",
+	210: "Bus Chassis Identification Unit",
+	211: "Smart Card Terminal",
+	212: "Mobile Data Terminal",
+	213: "Vehicle Control Head Touch Screen",
+	214: "Silent Alarm Unit",
+	215: "Surveillance Microphone",
+	216: "Lighting Control Administrator Unit",
+	217: "Tractor/Trailer Bridge, Tractor Mounted",
+	218: "Tractor/Trailer Bridge, Trailer Mounted",
+	219: "Collision Avoidance Systems",
+	220: "Tachograph",
+	221: "Driver Information Center #2",
+	222: "Driveline Retarder",
+	223: "Transmission Shift Console, Primary",
+	224: "Parking Heater",
+	225: "Weighing System, Axle Group #1",
+	226: "Weighing System, Axle Group #2",
+	227: "Weighing System, Axle Group #3",
+	228: "Weighing System, Axle Group #4",
+	229: "Weighing System, Axle Group #5",
+	230: "Weighing System, Axle Group #6",
+	231: "Communication Unit, Cellular",
+	232: "Safety Restraint System",
+	233: "Intersection Preemption Emitter",
+	234: "Instrument Cluster #2",
+	235: "Engine Oil Control System",
+	236: "Entry Assist Control #1",
+	237: "Entry Assist Control #2",
+	238: "Idle Adjust System",
+	239: "Passenger Counter Unit #2",
+	240: "Passenger Counter Unit #3",
+	241: "Fuel Tank Monitor",
+	242: "Axles, Trailer #4",
+	243: "Axles, Trailer #5",
+	244: "Diagnostic Systems, Trailer #4",
+	245: "Diagnostic Systems, Trailer #5",
+	246: "Brakes, Trailer #4",
+	247: "Brakes, Trailer #5",
+	248: "Forward Road Image Processor",
+	249: "Body Controller",
+	250: "Steering Column Unit",
+	251: "Reserved",
+	252: "Reserved",
+	253: "Reserved",
+	254: "Null Message Identifier",
+	255: "Reserved",
+}
+
+// LookupMid returns the name of a J1587 message identifier.
+func LookupMid(mid int) (string, bool) {
+	namesMtx.RLock()
+	defer namesMtx.RUnlock()
+
+	name, ok := midNames[mid]
+	return name, ok
+}
+
+// MidName returns the name of a J1587 message identifier or "Unknown".
+func MidName(mid int) string {
+	name, ok := LookupMid(mid)
+	if !ok {
+		return "Unknown"
+	}
+	return name
+}
@@ -0,0 +1,130 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMultisectionTimeout is how long the sections of a PID 192 parameter
+// may take to arrive.
+const DefaultMultisectionTimeout = 2 * time.Second
+
+// MultisectionHeader is the start of PID 192 data: the PID being sent and a
+// section byte whose high nibble is the last section number and low nibble
+// the current section number.
+type MultisectionHeader struct {
+	Pid         int
+	Section     int
+	LastSection int
+}
+
+// ParseMultisection decodes PID 192 data, not including its byte count, into
+// its header and the section's data.
+func ParseMultisection(data []byte) (*MultisectionHeader, []byte, error) {
+	if len(data) < 2 {
+		return nil, nil, fmt.Errorf("multisection parameter expected length > '1' got '%d'", len(data))
+	}
+
+	h := &MultisectionHeader{
+		Pid:         int(data[0]),
+		Section:     int(data[1] & 0x0F),
+		LastSection: int(data[1] >> 4),
+	}
+
+	if h.Section > h.LastSection {
+		return nil, nil, fmt.Errorf("multisection section '%d' is after the last section '%d'", h.Section, h.LastSection)
+	}
+
+	return h, data[2:], nil
+}
+
+type multisectionKey struct {
+	mid int
+	pid int
+}
+
+type multisection struct {
+	sections [][]byte
+	started  time.Time
+}
+
+// MultisectionAssembler collects the sections of PID 192 parameters per MID
+// and section parameter.
+type MultisectionAssembler struct {
+	timeout time.Duration
+
+	mtx     *sync.Mutex
+	pending map[multisectionKey]*multisection
+}
+
+func NewMultisectionAssembler(timeout time.Duration) *MultisectionAssembler {
+	return &MultisectionAssembler{
+		timeout: timeout,
+		mtx:     new(sync.Mutex),
+		pending: map[multisectionKey]*multisection{},
+	}
+}
+
+// Add adds a section and returns the completed parameter once every section
+// has arrived, otherwise nil. Sections may arrive in any order, and a section
+// arriving again, or with a different last section, restarts the parameter.
+func (a *MultisectionAssembler) Add(mid int, h *MultisectionHeader, data []byte) (*Parameter, error) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	key := multisectionKey{mid, h.Pid}
+
+	m, ok := a.pending[key]
+	if !ok || len(m.sections) != h.LastSection+1 || m.sections[h.Section] != nil {
+		m = &multisection{
+			sections: make([][]byte, h.LastSection+1),
+			started:  time.Now(),
+		}
+		a.pending[key] = m
+	}
+
+	m.sections[h.Section] = append([]byte{}, data...)
+
+	assembled := []byte{}
+	for _, s := range m.sections {
+		if s == nil {
+			return nil, nil
+		}
+		assembled = append(assembled, s...)
+	}
+
+	delete(a.pending, key)
+
+	p := NewParameter(h.Pid, assembled)
+	err := decodeParameter(mid, &p)
+	return &p, err
+}
+
+// Expire drops parameters whose sections did not all arrive in time and
+// returns a warning for each. It must be called periodically as well as when
+// messages arrive, or a parameter left incomplete on a quiet bus is never
+// reported.
+func (a *MultisectionAssembler) Expire() []string {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	warnings := []string{}
+	for key, m := range a.pending {
+		if time.Since(m.started) < a.timeout {
+			continue
+		}
+
+		received := 0
+		for _, s := range m.sections {
+			if s != nil {
+				received++
+			}
+		}
+
+		warnings = append(warnings, fmt.Sprintf("multisection PID %d from MID %d timed out with %d of %d sections", key.pid, key.mid, received, len(m.sections)))
+		delete(a.pending, key)
+	}
+
+	return warnings
+}
@@ -0,0 +1,165 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+type section struct {
+	section int
+	data    []byte
+}
+
+func TestMultisectionAssembler(t *testing.T) {
+	tests := []struct {
+		name     string
+		last     int
+		sections []section
+		data     []byte
+	}{
+		{"single", 0, []section{{0, []byte{1, 2}}}, []byte{1, 2}},
+		{"in order", 2, []section{{0, []byte{1, 2}}, {1, []byte{3}}, {2, []byte{4, 5}}}, []byte{1, 2, 3, 4, 5}},
+		{"out of order", 2, []section{{2, []byte{4, 5}}, {0, []byte{1, 2}}, {1, []byte{3}}}, []byte{1, 2, 3, 4, 5}},
+		{"restart", 2, []section{{0, []byte{9}}, {1, []byte{9}}, {0, []byte{1, 2}}, {1, []byte{3}}, {2, []byte{4, 5}}}, []byte{1, 2, 3, 4, 5}},
+		{"restart by a repeated section", 2, []section{{0, []byte{9}}, {2, []byte{9}}, {2, []byte{4, 5}}, {1, []byte{3}}, {0, []byte{1, 2}}}, []byte{1, 2, 3, 4, 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewMultisectionAssembler(DefaultMultisectionTimeout)
+
+			var p *Parameter
+			for i, s := range tt.sections {
+				var err error
+				p, err = a.Add(128, &MultisectionHeader{Pid: 243, Section: s.section, LastSection: tt.last}, s.data)
+				if err != nil {
+					t.Fatalf("add failed: %v", err)
+				}
+				if p != nil && i != len(tt.sections)-1 {
+					t.Fatalf("completed early at section %d with %v", i, p.Data)
+				}
+			}
+
+			if p == nil {
+				t.Fatal("not completed")
+			}
+			if p.Pid != 243 || !bytes.Equal(p.Data, tt.data) {
+				t.Errorf("expected PID 243 %v got PID %d %v", tt.data, p.Pid, p.Data)
+			}
+			if w := a.Expire(); len(w) != 0 {
+				t.Errorf("expected nothing pending got %v", w)
+			}
+		})
+	}
+}
+
+func TestMultisectionAssemblerKeepsMidsApart(t *testing.T) {
+	a := NewMultisectionAssembler(DefaultMultisectionTimeout)
+
+	p, _ := a.Add(128, &MultisectionHeader{Pid: 243, Section: 0, LastSection: 1}, []byte{1})
+	if p != nil {
+		t.Fatal("completed early")
+	}
+	p, _ = a.Add(130, &MultisectionHeader{Pid: 243, Section: 1, LastSection: 1}, []byte{2})
+	if p != nil {
+		t.Fatalf("completed with a section from another MID %v", p.Data)
+	}
+}
+
+func TestMultisectionAssemblerExpire(t *testing.T) {
+	a := NewMultisectionAssembler(50 * time.Millisecond)
+
+	a.Add(128, &MultisectionHeader{Pid: 243, Section: 1, LastSection: 2}, []byte{3})
+
+	if w := a.Expire(); len(w) != 0 {
+		t.Fatalf("expired early %v", w)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	w := a.Expire()
+	if len(w) != 1 || w[0] != "multisection PID 243 from MID 128 timed out with 1 of 3 sections" {
+		t.Fatalf("unexpected warnings %v", w)
+	}
+	if w := a.Expire(); len(w) != 0 {
+		t.Errorf("expected the parameter dropped got %v", w)
+	}
+}
+
+func TestParseMultisection(t *testing.T) {
+	h, data, err := ParseMultisection([]byte{243, 0x21, 1, 2})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if h.Pid != 243 || h.Section != 1 || h.LastSection != 2 || !bytes.Equal(data, []byte{1, 2}) {
+		t.Errorf("unexpected header %+v data %v", h, data)
+	}
+
+	if _, _, err := ParseMultisection([]byte{243}); err == nil {
+		t.Error("expected an error for a missing section byte")
+	}
+	if _, _, err := ParseMultisection([]byte{243, 0x13}); err == nil {
+		t.Error("expected an error for a section after the last")
+	}
+}
+
+func TestJ1587InterpreterMultisection(t *testing.T) {
+	tests := []struct {
+		name     string
+		messages [][]byte
+		pid      int
+	}{
+		{"page 1", [][]byte{{128, 192, 4, 243, 0x10, 'V', 'L'}, {128, 192, 4, 243, 0x11, 'U', '1'}}, 243},
+		{"page 2", [][]byte{{128, 255, 192, 4, 3, 0x10, 'V', 'L'}, {128, 255, 192, 4, 3, 0x11, 'U', '1'}}, 259},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i := NewJ1587Interpreter()
+
+			var in *Interpretation
+			for _, raw := range tt.messages {
+				m, err := ParseJ1587Message(raw)
+				if err != nil {
+					t.Fatalf("parse failed: %v", err)
+				}
+				in, err = i.Interpret(m)
+				if err != nil {
+					t.Fatalf("interpret failed: %v", err)
+				}
+			}
+
+			if len(in.Parameters) != 1 || len(in.Parameters[0].Children) != 1 {
+				t.Fatalf("expected one completed parameter got %+v", in.Parameters)
+			}
+			c := in.Parameters[0].Children[0]
+			if c.Pid != tt.pid || !bytes.Equal(c.Data, []byte("VLU1")) {
+				t.Errorf("expected PID %d 'VLU1' got PID %d %v", tt.pid, c.Pid, c.Data)
+			}
+		})
+	}
+}
+
+func TestJ1587InterpreterExpireWithoutTraffic(t *testing.T) {
+	i := NewJ1587Interpreter()
+	i.lazyInit()
+	i.multisections = NewMultisectionAssembler(50 * time.Millisecond)
+
+	m, err := ParseJ1587Message([]byte{128, 192, 4, 243, 0x10, 'V', 'L'})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	_, err = i.Interpret(m)
+	if err != nil {
+		t.Fatalf("interpret failed: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+
+	w := i.Expire()
+	if len(w) != 1 || !strings.Contains(w[0], "timed out with 1 of 2 sections") {
+		t.Errorf("expected a timeout warning got %v", w)
+	}
+}
@@ -0,0 +1,84 @@
+package common
+
+import "fmt"
+
+type Parameter struct {
+	Pid  int    `json:"pid"`
+	Name string `json:"name"`
+	Data Bytes  `json:"data"`
+
+	// Value is the decoded value, a float64 in Unit for scaled PIDs or a
+	// string for text PIDs, or nil if the PID has no known decoding.
+	Value interface{} `json:"value,omitempty"`
+	Unit  string      `json:"unit,omitempty"`
+}
+
+// NewParameter names the parameter and decodes its value.
+func NewParameter(pid int, data []byte) Parameter {
+	p := Parameter{
+		Pid:  pid,
+		Name: PidName(pid),
+		Data: data,
+	}
+	decodeValue(&p)
+
+	return p
+}
+
+// ParseParameters walks the PID and data pairs of a J1587 message body, the
+// bytes following the MID. Variable length data does not include its count
+// byte. The parameters parsed before any error are returned with it.
+func ParseParameters(body []byte) ([]Parameter, error) {
+	parameters := []Parameter{}
+
+	i := 0
+	for i < len(body) {
+		pid := int(body[i])
+		i++
+
+		if pid == 255 {
+			if i == len(body) {
+				return parameters, fmt.Errorf("page 2 extension at byte '%d' is missing its PID", i-1)
+			}
+			pid = 256 + int(body[i])
+			i++
+		}
+
+		var data []byte
+		switch l := PidLengthClass(pid); l {
+		case SingleByte, DoubleByte:
+			n := 1
+			if l == DoubleByte {
+				n = 2
+			}
+			if i+n > len(body) {
+				return parameters, fmt.Errorf("PID '%d' expected '%d' data bytes got '%d'", pid, n, len(body)-i)
+			}
+			data = body[i : i+n]
+			i += n
+			break
+		case VariableLength:
+			if i == len(body) {
+				return parameters, fmt.Errorf("PID '%d' is missing its byte count", pid)
+			}
+			n := int(body[i])
+			i++
+			if i+n > len(body) {
+				return parameters, fmt.Errorf("PID '%d' expected '%d' data bytes got '%d'", pid, n, len(body)-i)
+			}
+			data = body[i : i+n]
+			i += n
+			break
+		case DataLinkEscape:
+			data = body[i:]
+			i = len(body)
+			break
+		default:
+			return parameters, fmt.Errorf("PID '%d' extends to an unsupported page", pid)
+		}
+
+		parameters = append(parameters, NewParameter(pid, data))
+	}
+
+	return parameters, nil
+}
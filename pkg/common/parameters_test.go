@@ -0,0 +1,90 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+type parsedParameter struct {
+	pid  int
+	data []byte
+}
+
+func TestParseParameters(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       []byte
+		parameters []parsedParameter
+	}{
+		{"empty", []byte{}, []parsedParameter{}},
+		{"single byte", []byte{84, 100}, []parsedParameter{{84, []byte{100}}}},
+		{"last single byte PID", []byte{127, 1}, []parsedParameter{{127, []byte{1}}}},
+		{"double byte", []byte{190, 4, 8}, []parsedParameter{{190, []byte{4, 8}}}},
+		{"first double byte PID", []byte{128, 84, 130}, []parsedParameter{{128, []byte{84, 130}}}},
+		{"variable length", []byte{243, 3, 'V', 'L', 'U'}, []parsedParameter{{243, []byte("VLU")}}},
+		{"empty variable length", []byte{194, 0}, []parsedParameter{{194, []byte{}}}},
+		{"data link escape", []byte{254, 1, 2, 3}, []parsedParameter{{254, []byte{1, 2, 3}}}},
+		{"data link escape after a PID", []byte{84, 100, 254, 1, 2}, []parsedParameter{{84, []byte{100}}, {254, []byte{1, 2}}}},
+		{"page 2 single byte", []byte{255, 3, 1}, []parsedParameter{{259, []byte{1}}}},
+		{"page 2 double byte", []byte{255, 128, 1, 2}, []parsedParameter{{384, []byte{1, 2}}}},
+		{"page 2 variable length", []byte{255, 192, 2, 1, 2}, []parsedParameter{{448, []byte{1, 2}}}},
+		{"page 2 data link escape", []byte{255, 254, 1, 2}, []parsedParameter{{510, []byte{1, 2}}}},
+		{
+			"several",
+			[]byte{84, 100, 190, 4, 8, 255, 3, 1, 243, 1, 'V'},
+			[]parsedParameter{{84, []byte{100}}, {190, []byte{4, 8}}, {259, []byte{1}}, {243, []byte("V")}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parameters, err := ParseParameters(tt.body)
+			if err != nil {
+				t.Fatalf("parse failed: %v", err)
+			}
+			checkParameters(t, parameters, tt.parameters)
+		})
+	}
+}
+
+func TestParseParametersTruncated(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       []byte
+		parameters []parsedParameter
+	}{
+		{"single byte missing data", []byte{84}, []parsedParameter{}},
+		{"double byte missing a byte", []byte{84, 100, 190, 4}, []parsedParameter{{84, []byte{100}}}},
+		{"variable length missing count", []byte{243}, []parsedParameter{}},
+		{"variable length short", []byte{243, 3, 'V'}, []parsedParameter{}},
+		{"page 2 missing PID", []byte{84, 100, 255}, []parsedParameter{{84, []byte{100}}}},
+		{"page 2 missing data", []byte{255, 3}, []parsedParameter{}},
+		{"page 3", []byte{255, 255, 1}, []parsedParameter{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parameters, err := ParseParameters(tt.body)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			checkParameters(t, parameters, tt.parameters)
+		})
+	}
+}
+
+func checkParameters(t *testing.T, got []Parameter, want []parsedParameter) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("expected %d parameters got %+v", len(want), got)
+	}
+	for i, w := range want {
+		if got[i].Pid != w.pid || !bytes.Equal(got[i].Data, w.data) {
+			t.Errorf("parameter %d expected PID %d %v got PID %d %v", i, w.pid, w.data, got[i].Pid, []byte(got[i].Data))
+		}
+		if got[i].Name != PidName(w.pid) {
+			t.Errorf("parameter %d expected name '%s' got '%s'", i, PidName(w.pid), got[i].Name)
+		}
+	}
+}
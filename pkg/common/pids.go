@@ -0,0 +1,339 @@
+package common
+
+import "fmt"
+
+// PidLength is how many data bytes follow a PID. J1587 fixes it by the PID's
+// range within each page.
+type PidLength int
+
+const (
+	SingleByte PidLength = iota
+	DoubleByte
+	VariableLength
+	DataLinkEscape
+	PageExtension
+)
+
+func (l PidLength) String() string {
+	switch l {
+	case SingleByte:
+		return "1 byte"
+	case DoubleByte:
+		return "2 bytes"
+	case VariableLength:
+		return "variable"
+	case DataLinkEscape:
+		return "data link escape"
+	case PageExtension:
+		return "page extension"
+	default:
+		return fmt.Sprintf("PidLength(%d)", int(l))
+	}
+}
+
+// PidLengthClass returns the data length class of a page 1 (0-255) or page 2
+// (256-511) PID.
+func PidLengthClass(pid int) PidLength {
+	switch p := pid % 256; {
+	case p < 128:
+		return SingleByte
+	case p < 192:
+		return DoubleByte
+	case p < 254:
+		return VariableLength
+	case p == 254:
+		return DataLinkEscape
+	default:
+		return PageExtension
+	}
+}
+
+type PidDefinition struct {
+	Pid    int
+	Name   string
+	Length PidLength
+}
+
+// pidNames are the SAE J1587 parameter identifiers. Page 2 PIDs are numbered
+// 256-511, i.e. 256 plus the PID byte following the 255 extension.
+//
+// Page 2 only lists the restart PIDs and the page 2 counterparts of the page 1
+// protocol PIDs; the other page 2 PIDs are named "Unknown" but still get their
+// length class, and can be named with a definitions file.
+var pidNames = map[int]string{
+	0:   "Request Parameter",
+	1:   "Invalid Data Parameter",
+	2:   "Transmitter System Status",
+	3:   "Transmitter System Identification",
+	4:   "Underrange Warning Condition",
+	5:   "Overrange Warning Condition",
+	6:   "Underrange Critical Condition",
+	7:   "Overrange Critical Condition",
+	8:   "Brake System Air Pressure Low Warning Switch Status",
+	9:   "Axle Lift Status",
+	10:  "Axle Slider Status",
+	11:  "Cargo Securement",
+	12:  "Brake Stroke Status",
+	13:  "Entry Assist Position/Deployment",
+	14:  "Entry Assist Motor Current",
+	15:  "Fuel Supply Pump Inlet Pressure",
+	16:  "Suction Side Fuel Filter Differential Pressure",
+	17:  "Engine Oil Level Remote Reservoir",
+	18:  "Extended Range Fuel Pressure",
+	19:  "Extended Range Engine Oil Pressure",
+	20:  "Extended Range Engine Coolant Pressure",
+	21:  "Engine ECU Temperature",
+	22:  "Extended Engine Crankcase Blow-by Pressure",
+	23:  "Generator Oil Pressure",
+	24:  "Generator Coolant Temperature",
+	25:  "Air Conditioner System Status #2",
+	26:  "Estimated Percent Fan Speed",
+	27:  "Percent Exhaust Gas Recirculation Valve #1 Position",
+	28:  "Percent Accelerator Position #3",
+	29:  "Percent Accelerator Position #2",
+	30:  "Crankcase Blow-by Pressure",
+	31:  "Transmission Range Position",
+	32:  "Transmission Splitter Position",
+	33:  "Clutch Cylinder Position",
+	34:  "Clutch Cylinder Actuator Status",
+	35:  "Shift Finger Actuator Status #2",
+	36:  "Clutch Plates Wear Condition",
+	37:  "Transmission Tank Air Pressure",
+	38:  "Second Fuel Level (Right Side)",
+	39:  "Tire Pressure Check Interval",
+	40:  "Engine Retarder Switches Status",
+	41:  "Cruise Control Switches Status",
+	42:  "Pressure Switch Status",
+	43:  "Ignition Switch Status",
+	44:  "Attention/Warning Indicator Lamps Status",
+	45:  "Inlet Air Heater Status",
+	46:  "Vehicle Wet Tank Pressure",
+	47:  "Retarder Status",
+	48:  "Extended Range Barometric Pressure",
+	49:  "ABS Control Status",
+	50:  "Air Conditioner Compressor Clutch/Refrigerant Switch Status",
+	51:  "Throttle Position",
+	52:  "Engine Intercooler Temperature",
+	53:  "Transmission Synchronizer Clutch Value",
+	54:  "Transmission Synchronizer Brake Value",
+	55:  "Shift Finger Positional Status",
+	56:  "Transmission Range Switch Status",
+	57:  "Transmission Actuator Status #2",
+	58:  "Shift Finger Actuator Status",
+	59:  "Shift Finger Gear Position",
+	60:  "Shift Finger Rail Position",
+	61:  "Parking Brake Actuator Status",
+	62:  "Retarder Inhibit Status",
+	63:  "Transmission Actuator Status #1",
+	64:  "Direction Switch Status",
+	65:  "Service Brake Switch Status",
+	66:  "Vehicle Enabling Component Status",
+	67:  "Shift Request Switch Status",
+	68:  "Torque Limiting Factor",
+	69:  "Two Speed Axle Switch Status",
+	70:  "Parking Brake Switch Status",
+	71:  "Idle Shutdown Timer Status",
+	72:  "Blower Bypass Value Position",
+	73:  "Auxiliary Water Pump Pressure",
+	74:  "Maximum Road Speed Limit",
+	75:  "Steering Axle Temperature",
+	76:  "Axle Lift Air Pressure",
+	77:  "Forward Rear Drive Axle Temperature",
+	78:  "Rear Rear-Drive Axle Temperature",
+	79:  "Road Surface Temperature",
+	80:  "Washer Fluid Level",
+	81:  "Particulate Trap Inlet Pressure",
+	82:  "Air Start Pressure",
+	83:  "Road Speed Limit Status",
+	84:  "Road Speed",
+	85:  "Cruise Control Status",
+	86:  "Cruise Control Set Speed",
+	87:  "Cruise Control High-Set Limit Speed",
+	88:  "Cruise Control Low-Set Limit Speed",
+	89:  "Power Takeoff Status",
+	90:  "PTO Oil Temperature",
+	91:  "Percent Accelerator Pedal Position",
+	92:  "Percent Engine Load",
+	93:  "Output Torque",
+	94:  "Fuel Delivery Pressure",
+	95:  "Fuel Filter Differential Pressure",
+	96:  "Fuel Level",
+	97:  "Water in Fuel Indicator",
+	98:  "Engine Oil Level",
+	99:  "Engine Oil Filter Differential Pressure",
+	100: "Engine Oil Pressure",
+	101: "Crankcase Pressure",
+	102: "Boost Pressure",
+	103: "Turbo Speed",
+	104: "Turbo Oil Pressure",
+	105: "Intake Manifold Temperature",
+	106: "Air Inlet Pressure",
+	107: "Air Filter Differential Pressure",
+	108: "Barometric Pressure",
+	109: "Coolant Pressure",
+	110: "Engine Coolant Temperature",
+	111: "Coolant Level",
+	112: "Coolant Filter Differential Pressure",
+	113: "Governor Droop",
+	114: "Net Battery Current",
+	115: "Alternator Current",
+	116: "Brake Application Pressure",
+	117: "Brake Primary Pressure",
+	118: "Brake Secondary Pressure",
+	119: "Hydraulic Retarder Pressure",
+	120: "Hydraulic Retarder Oil Temperature",
+	121: "Engine Retarder Status",
+	122: "Engine Retarder Percent",
+	123: "Clutch Pressure",
+	124: "Transmission Oil Level",
+	125: "Transmission Oil Level High/Low",
+	126: "Transmission Filter Differential Pressure",
+	127: "Transmission Oil Pressure",
+	128: "Component-specific Parameter Request",
+	129: "Injector Metering Rail #2 Pressure",
+	130: "Power Specific Fuel Economy",
+	131: "Exhaust Back Pressure",
+	132: "Mass Air Flow",
+	133: "Average Fuel Rate",
+	134: "Wheel Speed Sensor Status",
+	135: "Extended Range Fuel Delivery Pressure (Absolute)",
+	136: "Auxiliary Vacuum Pressure Reading",
+	137: "Auxiliary Gage Pressure Reading #1",
+	138: "Auxiliary Absolute Pressure Reading",
+	139: "Tire Pressure Control System Channel Functional Mode",
+	140: "Tire Pressure Control System Solenoid Status",
+	141: "Trailer, Tag or Push Channel Tire Pressure Target",
+	142: "Drive Channel Tire Pressure Target",
+	143: "Steer Channel Tire Pressure Target",
+	144: "Trailer, Tag or Push Channel Tire Pressure",
+	145: "Drive Channel Tire Pressure",
+	146: "Steer Channel Tire Pressure",
+	147: "Average Fuel Economy (Natural Gas)",
+	148: "Instantaneous Fuel Economy (Natural Gas)",
+	149: "Fuel Mass Flow Rate (Natural Gas)",
+	150: "PTO Engagement Control Status",
+	151: "ATC Control Status",
+	152: "Number of ECU Resets",
+	153: "Crankcase Pressure",
+	154: "Auxiliary Input and Output Status #2",
+	155: "Auxiliary Input and Output Status #1",
+	156: "Injector Timing Rail Pressure",
+	157: "Injector Metering Rail Pressure",
+	158: "Battery Potential (Voltage), Switched",
+	159: "Gas Supply Pressure",
+	160: "Main Shaft Speed",
+	161: "Input Shaft Speed",
+	162: "Transmission Range Selected",
+	163: "Transmission Range Attained",
+	164: "Injection Control Pressure",
+	165: "Compass Bearing",
+	166: "Rated Engine Power",
+	167: "Alternator Potential (Voltage)",
+	168: "Battery Potential (Voltage)",
+	169: "Cargo Ambient Temperature",
+	170: "Cab Interior Temperature",
+	171: "Ambient Air Temperature",
+	172: "Air Inlet Temperature",
+	173: "Exhaust Gas Temperature",
+	174: "Fuel Temperature",
+	175: "Engine Oil Temperature",
+	176: "Turbo Oil Temperature",
+	177: "Transmission Oil Temperature",
+	178: "Front Axle Weight",
+	179: "Rear Axle Weight",
+	180: "Trailer Weight",
+	181: "Cargo Weight",
+	182: "Trip Fuel",
+	183: "Fuel Rate (Instantaneous)",
+	184: "Instantaneous Fuel Economy",
+	185: "Average Fuel Economy",
+	186: "Power Takeoff Speed",
+	187: "Power Takeoff Set Speed",
+	188: "Idle Engine Speed",
+	189: "Rated Engine Speed",
+	190: "Engine Speed",
+	191: "Transmission Output Shaft Speed",
+	192: "Multisection Parameter",
+	193: "Transmitter System Diagnostic Table",
+	194: "Transmitter System Diagnostic Code and Occurrence Count Table",
+	195: "Diagnostic Data Request/Clear Count",
+	196: "Diagnostic Data/Count Clear Response",
+	197: "Connection Management",
+	198: "Connection Mode Data Transfer",
+	199: "Traction Control Disable State",
+	209: "ABS Control Status, Trailer",
+	210: "Tire Temperature (By Sequence Number)",
+	211: "Tire Pressure (By Sequence Number)",
+	212: "Tire Pressure Target (By Sequence Number)",
+	213: "Wheel End Assembly Vent Status (By Sequence Number)",
+	216: "Other ECUs Have Reported Diagnostic Codes Affecting Operation",
+	218: "State Line Crossing",
+	219: "Current State and Country",
+	220: "Engine Torque History",
+	221: "Anti-theft Request",
+	222: "Anti-theft Status",
+	223: "Auxiliary A/D Counts",
+	224: "Immobilizer Security Code",
+	225: "Text Message Acknowledged",
+	226: "Text Message to Display",
+	227: "Text Message Display Type",
+	228: "Speed Sensor Calibration",
+	229: "Total Fuel Used (Natural Gas)",
+	230: "Total Idle Fuel Used (Natural Gas)",
+	231: "Trip Fuel (Natural Gas)",
+	232: "DGPS Differential Correction",
+	233: "Unit Number (Power Unit)",
+	234: "Software Identification",
+	235: "Total Idle Hours",
+	236: "Total Idle Fuel Used",
+	237: "Vehicle Identification Number",
+	238: "Velocity Vector",
+	239: "Vehicle Position",
+	240: "Change Reference Number",
+	241: "Tire Pressure by Position",
+	242: "Tire Temperature by Position",
+	243: "Component Identification",
+	244: "Trip Distance",
+	245: "Total Vehicle Distance",
+	246: "Total Vehicle Hours",
+	247: "Total Engine Hours",
+	248: "Total PTO Hours",
+	249: "Total Engine Revolutions",
+	250: "Total Fuel Used",
+	251: "Clock",
+	252: "Date",
+	253: "Elapsed Time",
+	254: "Data Link Escape",
+	255: "Extension",
+	256: "Request Parameter",
+	257: "Cold Restart of Specific Component",
+	258: "Warm Restart of Specific Component",
+	259: "Acknowledgement of Component Restart",
+	448: "Multisection Parameter",
+	510: "Data Link Escape",
+	511: "Extension",
+}
+
+// LookupPid returns the definition of a J1587 parameter identifier. The
+// length class is filled in even for unassigned PIDs.
+func LookupPid(pid int) (PidDefinition, bool) {
+	namesMtx.RLock()
+	name, ok := pidNames[pid]
+	namesMtx.RUnlock()
+
+	if !ok {
+		name = "Unknown"
+	}
+
+	return PidDefinition{
+		Pid:    pid,
+		Name:   name,
+		Length: PidLengthClass(pid),
+	}, ok
+}
+
+// PidName returns the name of a J1587 parameter identifier or "Unknown".
+func PidName(pid int) string {
+	d, _ := LookupPid(pid)
+	return d.Name
+}
@@ -0,0 +1,31 @@
+package common
+
+import "testing"
+
+func TestLookupPid(t *testing.T) {
+	tests := []struct {
+		pid    int
+		name   string
+		length PidLength
+		ok     bool
+	}{
+		{84, "Road Speed", SingleByte, true},
+		{190, "Engine Speed", DoubleByte, true},
+		{192, "Multisection Parameter", VariableLength, true},
+		{254, "Data Link Escape", DataLinkEscape, true},
+		{255, "Extension", PageExtension, true},
+		{259, "Acknowledgement of Component Restart", SingleByte, true},
+		{300, "Unknown", SingleByte, false},
+		{400, "Unknown", DoubleByte, false},
+		{448, "Multisection Parameter", VariableLength, true},
+		{510, "Data Link Escape", DataLinkEscape, true},
+		{511, "Extension", PageExtension, true},
+	}
+
+	for _, tt := range tests {
+		d, ok := LookupPid(tt.pid)
+		if d.Pid != tt.pid || d.Name != tt.name || d.Length != tt.length || ok != tt.ok {
+			t.Errorf("PID %d expected '%s' %v %v got '%s' %v %v", tt.pid, tt.name, tt.length, tt.ok, d.Name, d.Length, ok)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package common
+
+import (
+	"encoding/binary"
+	"strconv"
+	"strings"
+)
+
+type scaling struct {
+	resolution float64
+	offset     float64
+	unit       string
+	signed     bool
+}
+
+// scalings are the J1587 engineering units for the common standard PIDs.
+// Values are little endian.
+var scalings = map[int]scaling{
+	74:  {resolution: 0.5, unit: "mph"},
+	84:  {resolution: 0.5, unit: "mph"},
+	86:  {resolution: 0.5, unit: "mph"},
+	87:  {resolution: 0.5, unit: "mph"},
+	88:  {resolution: 0.5, unit: "mph"},
+	91:  {resolution: 0.4, unit: "%"},
+	92:  {resolution: 0.5, unit: "%"},
+	96:  {resolution: 0.5, unit: "%"},
+	98:  {resolution: 0.5, unit: "%"},
+	100: {resolution: 0.5, unit: "psi"},
+	102: {resolution: 0.125, unit: "psi"},
+	105: {resolution: 1, unit: "°F"},
+	108: {resolution: 0.0625, unit: "psi"},
+	110: {resolution: 1, unit: "°F"},
+	111: {resolution: 0.5, unit: "%"},
+	117: {resolution: 0.6, unit: "psi"},
+	118: {resolution: 0.6, unit: "psi"},
+	158: {resolution: 0.05, unit: "V"},
+	160: {resolution: 0.25, unit: "rpm"},
+	161: {resolution: 0.25, unit: "rpm"},
+	167: {resolution: 0.05, unit: "V"},
+	168: {resolution: 0.05, unit: "V"},
+	171: {resolution: 0.25, unit: "°F", signed: true},
+	172: {resolution: 0.25, unit: "°F", signed: true},
+	173: {resolution: 0.25, unit: "°F", signed: true},
+	174: {resolution: 0.25, unit: "°F", signed: true},
+	175: {resolution: 0.25, unit: "°F", signed: true},
+	177: {resolution: 0.25, unit: "°F", signed: true},
+	182: {resolution: 0.125, unit: "gal"},
+	183: {resolution: 1.0 / 64, unit: "gal/h"},
+	184: {resolution: 1.0 / 256, unit: "mpg"},
+	185: {resolution: 1.0 / 256, unit: "mpg"},
+	186: {resolution: 0.25, unit: "rpm"},
+	187: {resolution: 0.25, unit: "rpm"},
+	188: {resolution: 0.25, unit: "rpm"},
+	189: {resolution: 0.25, unit: "rpm"},
+	190: {resolution: 0.25, unit: "rpm"},
+	191: {resolution: 0.25, unit: "rpm"},
+	235: {resolution: 0.05, unit: "h"},
+	236: {resolution: 0.125, unit: "gal"},
+	244: {resolution: 0.1, unit: "mi"},
+	245: {resolution: 0.1, unit: "mi"},
+	246: {resolution: 0.05, unit: "h"},
+	247: {resolution: 0.05, unit: "h"},
+	248: {resolution: 0.05, unit: "h"},
+	249: {resolution: 1000, unit: "rev"},
+	250: {resolution: 0.125, unit: "gal"},
+}
+
+// textPids carry ASCII data.
+var textPids = map[int]bool{
+	233: true,
+	234: true,
+	237: true,
+	243: true,
+}
+
+func (s scaling) decode(data []byte) (float64, bool) {
+	var raw float64
+	switch len(data) {
+	case 1:
+		if s.signed {
+			raw = float64(int8(data[0]))
+		} else {
+			raw = float64(data[0])
+		}
+	case 2:
+		v := binary.LittleEndian.Uint16(data)
+		if s.signed {
+			raw = float64(int16(v))
+		} else {
+			raw = float64(v)
+		}
+	case 4:
+		v := binary.LittleEndian.Uint32(data)
+		if s.signed {
+			raw = float64(int32(v))
+		} else {
+			raw = float64(v)
+		}
+	default:
+		return 0, false
+	}
+
+	return raw*s.resolution + s.offset, true
+}
+
+func decodeValue(p *Parameter) {
+	if textPids[p.Pid] {
+		p.Value = strings.TrimRight(string(p.Data), "\x00")
+		return
+	}
+
+	s, ok := scalings[p.Pid]
+	if !ok {
+		return
+	}
+
+	// a fixed length PID with the wrong number of bytes, such as from a
+	// truncated message or a decoder, is left undecoded
+	switch PidLengthClass(p.Pid) {
+	case SingleByte:
+		if len(p.Data) != 1 {
+			return
+		}
+	case DoubleByte:
+		if len(p.Data) != 2 {
+			return
+		}
+	}
+
+	v, ok := s.decode(p.Data)
+	if !ok {
+		return
+	}
+
+	p.Value = v
+	p.Unit = s.unit
+}
+
+// FormatValue renders the decoded value with its unit, or "" if the
+// parameter has no decoded value.
+func (p Parameter) FormatValue() string {
+	switch v := p.Value.(type) {
+	case nil:
+		return ""
+	case float64:
+		s := strings.TrimRight(strings.TrimRight(strconv.FormatFloat(v, 'f', 4, 64), "0"), ".")
+		if p.Unit == "" {
+			return s
+		}
+		return s + " " + p.Unit
+	case string:
+		return strconv.Quote(v)
+	default:
+		return ""
+	}
+}
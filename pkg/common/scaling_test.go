@@ -0,0 +1,41 @@
+package common
+
+import "testing"
+
+func TestDecodeValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		pid   int
+		data  []byte
+		value string
+	}{
+		{"road speed", 84, []byte{100}, "50 mph"},
+		{"road speed zero", 84, []byte{0}, "0 mph"},
+		{"road speed top of range", 84, []byte{255}, "127.5 mph"},
+		{"throttle position", 91, []byte{250}, "100 %"},
+		{"battery potential", 168, []byte{0x18, 0x01}, "14 V"},
+		{"engine speed", 190, []byte{0x80, 0x25}, "2400 rpm"},
+		{"engine speed top of range", 190, []byte{0xFF, 0xFF}, "16383.75 rpm"},
+		{"ambient air temperature", 171, []byte{0x90, 0x01}, "100 °F"},
+		{"ambient air temperature below zero", 171, []byte{0x38, 0xFF}, "-50 °F"},
+		{"total vehicle distance", 245, []byte{0x10, 0x27, 0, 0}, "1000 mi"},
+		{"text", 237, []byte{'1', 'F', 'T', 0, 0}, "\"1FT\""},
+		{"no scaling", 0, []byte{84}, ""},
+		{"single byte PID given two bytes", 84, []byte{100, 1}, ""},
+		{"two byte PID given one byte", 190, []byte{0x80}, ""},
+		{"two byte PID given no bytes", 190, []byte{}, ""},
+		{"variable length PID of an unscaled length", 245, []byte{0x10, 0x27, 0}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParameter(tt.pid, tt.data)
+			if v := p.FormatValue(); v != tt.value {
+				t.Errorf("PID %d %v expected '%s' got '%s'", tt.pid, tt.data, tt.value, v)
+			}
+			if tt.value == "" && p.Value != nil {
+				t.Errorf("PID %d %v expected no value got %v", tt.pid, tt.data, p.Value)
+			}
+		})
+	}
+}
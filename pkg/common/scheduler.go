@@ -0,0 +1,162 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MinimumScheduleInterval keeps scheduled jobs from flooding the bus.
+const MinimumScheduleInterval = 50 * time.Millisecond
+
+// ScheduledJob describes a message sent repeatedly by a Scheduler.
+type ScheduledJob struct {
+	ID        int           `json:"id"`
+	Message   Bytes         `json:"message"`
+	Interval  time.Duration `json:"interval"`
+	Sent      int           `json:"sent"`
+	Failed    int           `json:"failed"`
+	LastError string        `json:"lastError,omitempty"`
+}
+
+func (j ScheduledJob) String() string {
+	s := fmt.Sprintf("#%d every %v: %v, sent %d, failed %d", j.ID, j.Interval, []byte(j.Message), j.Sent, j.Failed)
+	if j.LastError != "" {
+		s += fmt.Sprintf(", last error: %s", j.LastError)
+	}
+	return s
+}
+
+type scheduledJob struct {
+	ScheduledJob
+	stop chan struct{}
+}
+
+// Scheduler sends messages repeatedly at fixed intervals, such as heartbeat
+// traffic a component expects while it is tested.
+type Scheduler struct {
+	sender Sender
+
+	mtx    *sync.Mutex
+	jobs   map[int]*scheduledJob
+	nextID int
+}
+
+func NewScheduler(sender Sender) *Scheduler {
+	return &Scheduler{
+		sender: sender,
+		mtx:    new(sync.Mutex),
+		jobs:   map[int]*scheduledJob{},
+		nextID: 1,
+	}
+}
+
+// Start sends the message now and then every interval until the job is
+// stopped, returning the job's id. Failed sends are counted and the job
+// carries on.
+func (s *Scheduler) Start(message []byte, interval time.Duration) (int, error) {
+	if interval < MinimumScheduleInterval {
+		return 0, fmt.Errorf("interval '%v' is less than the minimum '%v'", interval, MinimumScheduleInterval)
+	}
+	if len(message) == 0 {
+		return 0, fmt.Errorf("message is empty")
+	}
+
+	s.mtx.Lock()
+	job := &scheduledJob{
+		ScheduledJob: ScheduledJob{
+			ID:       s.nextID,
+			Message:  append([]byte{}, message...),
+			Interval: interval,
+		},
+		stop: make(chan struct{}),
+	}
+	s.jobs[job.ID] = job
+	s.nextID++
+	s.mtx.Unlock()
+
+	go s.run(job)
+
+	return job.ID, nil
+}
+
+// Stop stops a job.
+func (s *Scheduler) Stop(id int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return fmt.Errorf("no scheduled job '%d'", id)
+	}
+
+	close(job.stop)
+	delete(s.jobs, id)
+
+	return nil
+}
+
+// StopAll stops every job.
+func (s *Scheduler) StopAll() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	for id, job := range s.jobs {
+		close(job.stop)
+		delete(s.jobs, id)
+	}
+}
+
+// Jobs lists the running jobs in the order they were started.
+func (s *Scheduler) Jobs() []ScheduledJob {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	jobs := []ScheduledJob{}
+	for _, job := range s.jobs {
+		jobs = append(jobs, job.ScheduledJob)
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	return jobs
+}
+
+func (s *Scheduler) run(job *scheduledJob) {
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.send(job)
+
+		select {
+		case <-job.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) send(job *scheduledJob) {
+	err := s.sender.Send(job.Message)
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if err == nil {
+		job.Sent++
+		job.LastError = ""
+		return
+	}
+
+	// only log when the error changes, rather than every interval while the
+	// device is disconnected
+	if err.Error() != job.LastError {
+		log.Printf("warn: scheduled job %d failed: %v", job.ID, err)
+	}
+	job.Failed++
+	job.LastError = err.Error()
+}
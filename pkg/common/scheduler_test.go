@@ -0,0 +1,135 @@
+package common
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// waitFor polls until the condition holds.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// stopped waits out a send the job may have had in flight, then checks that
+// nothing more is sent.
+func stopped(t *testing.T, sender *fakeSender) {
+	t.Helper()
+
+	time.Sleep(MinimumScheduleInterval)
+	for len(sender.sent) > 0 {
+		<-sender.sent
+	}
+	time.Sleep(2 * MinimumScheduleInterval)
+	sender.none(t)
+}
+
+func TestSchedulerStartErrors(t *testing.T) {
+	s := NewScheduler(newFakeSender())
+	defer s.StopAll()
+
+	_, err := s.Start([]byte{172, 0, 84}, MinimumScheduleInterval-time.Millisecond)
+	if err == nil {
+		t.Error("expected an error for an interval under the minimum")
+	}
+
+	_, err = s.Start([]byte{}, time.Second)
+	if err == nil {
+		t.Error("expected an error for an empty message")
+	}
+
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("expected no jobs got %v", jobs)
+	}
+}
+
+func TestSchedulerSendsRepeatedly(t *testing.T) {
+	sender := newFakeSender()
+	s := NewScheduler(sender)
+
+	message := []byte{172, 0, 84}
+	id, err := s.Start(message, MinimumScheduleInterval)
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if m := sender.next(t); !bytes.Equal(m, message) {
+			t.Fatalf("expected %v got %v", message, m)
+		}
+	}
+
+	err = s.Stop(id)
+	if err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	stopped(t, sender)
+
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("expected no jobs got %v", jobs)
+	}
+	if err := s.Stop(id); err == nil {
+		t.Error("expected an error stopping a stopped job")
+	}
+}
+
+func TestSchedulerStopAll(t *testing.T) {
+	sender := newFakeSender()
+	s := NewScheduler(sender)
+
+	for _, m := range [][]byte{{172, 0, 84}, {172, 0, 190}} {
+		_, err := s.Start(m, MinimumScheduleInterval)
+		if err != nil {
+			t.Fatalf("start failed: %v", err)
+		}
+	}
+
+	jobs := s.Jobs()
+	if len(jobs) != 2 || jobs[0].ID != 1 || jobs[1].ID != 2 {
+		t.Fatalf("expected jobs 1 and 2 got %v", jobs)
+	}
+
+	s.StopAll()
+	stopped(t, sender)
+
+	if jobs := s.Jobs(); len(jobs) != 0 {
+		t.Errorf("expected no jobs got %v", jobs)
+	}
+}
+
+func TestSchedulerCounts(t *testing.T) {
+	sender := newFakeSender()
+	sender.fail(fmt.Errorf("device is not connected"))
+	s := NewScheduler(sender)
+	defer s.StopAll()
+
+	_, err := s.Start([]byte{172, 0, 84}, MinimumScheduleInterval)
+	if err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	waitFor(t, func() bool {
+		return s.Jobs()[0].Failed >= 2
+	})
+	if j := s.Jobs()[0]; j.Sent != 0 || j.LastError != "device is not connected" {
+		t.Errorf("expected only failures got %v", j)
+	}
+
+	sender.fail(nil)
+
+	waitFor(t, func() bool {
+		return s.Jobs()[0].Sent >= 2
+	})
+	if j := s.Jobs()[0]; j.LastError != "" {
+		t.Errorf("expected the last error cleared by a send got %v", j)
+	}
+}
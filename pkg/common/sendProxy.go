@@ -1,9 +1,7 @@
 package common
 
 import (
-	"log"
-	"strconv"
-	"strings"
+	"github.com/pkg/errors"
 )
 
 type SendProxy struct {
@@ -14,21 +12,17 @@ func NewSendProxy(sender Sender) *SendProxy {
 	return &SendProxy{sender}
 }
 
-func (p *SendProxy) Send(message string) {
-	frags := strings.Split(message, " ")
-
-	m := []byte{}
-	for _, f := range frags {
-		i, err := strconv.Atoi(f)
-		if err != nil {
-			log.Println("warn: failed to send: %v", err)
-			return
-		}
-		m = append(m, byte(i))
+// Send parses a message expression, see ParseMessageExpression, and sends the
+// message, returning the bytes sent.
+func (p *SendProxy) Send(message string) ([]byte, error) {
+	m, err := ParseMessageExpression(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse message")
 	}
 
-	err := p.sender.Send(m)
+	err = p.sender.Send(m)
 	if err != nil {
-		log.Println("warn: failed to send: %v", err)
+		return m, errors.Wrap(err, "failed to send")
 	}
+	return m, nil
 }
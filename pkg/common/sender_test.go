@@ -0,0 +1,64 @@
+package common
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSender records the messages sent, or fails every send with err.
+type fakeSender struct {
+	mtx  sync.Mutex
+	err  error
+	sent chan []byte
+}
+
+func newFakeSender() *fakeSender {
+	return &fakeSender{
+		sent: make(chan []byte, 1000),
+	}
+}
+
+func (s *fakeSender) Send(message []byte) error {
+	s.mtx.Lock()
+	err := s.err
+	s.mtx.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	s.sent <- append([]byte{}, message...)
+	return nil
+}
+
+func (s *fakeSender) fail(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.err = err
+}
+
+// next waits for the next message sent.
+func (s *fakeSender) next(t *testing.T) []byte {
+	t.Helper()
+
+	select {
+	case m := <-s.sent:
+		return m
+	case <-time.After(time.Second):
+		t.Fatal("nothing sent")
+		return nil
+	}
+}
+
+// none checks nothing is sent for a while.
+func (s *fakeSender) none(t *testing.T) {
+	t.Helper()
+
+	select {
+	case m := <-s.sent:
+		t.Fatalf("unexpected message sent %v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// SimulationRule replies with a message whenever a PID is received from a
+// MID, or from any MID with AnyMid.
+type SimulationRule struct {
+	From  int
+	Pid   int
+	Reply []byte
+}
+
+// simulatedEcu is a component answering parameter requests from stored
+// values. Its transport sends values too long for one message and accepts
+// connection mode transfers addressed to it.
+type simulatedEcu struct {
+	mid        int
+	parameters map[int][]byte
+	transport  *Transport
+}
+
+// Simulator stands in for components missing from the bench. Simulated ECUs
+// answer PID 0 requests, and PID 128 requests addressed to them, with their
+// stored parameter values, and rules reply to any other PID.
+type Simulator struct {
+	sender Sender
+
+	mtx   *sync.Mutex
+	ecus  map[int]*simulatedEcu
+	rules []SimulationRule
+}
+
+func NewSimulator(sender Sender) *Simulator {
+	return &Simulator{
+		sender: sender,
+		mtx:    new(sync.Mutex),
+		ecus:   map[int]*simulatedEcu{},
+		rules:  []SimulationRule{},
+	}
+}
+
+// SetParameter stores a value a simulated ECU answers requests for with, in
+// the data syntax of a message expression, see EncodeParameter. The ECU is
+// added if it is not simulated yet.
+func (s *Simulator) SetParameter(mid int, pid int, value string) error {
+	if mid < 0 || mid > 255 {
+		return fmt.Errorf("mid '%d' expected between '0' and '255'", mid)
+	}
+
+	p, err := EncodeParameter(pid, value)
+	if err != nil {
+		return fmt.Errorf("pid '%d' value '%s': %v", pid, value, err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	ecu, ok := s.ecus[mid]
+	if !ok {
+		ecu = &simulatedEcu{
+			mid:        mid,
+			parameters: map[int][]byte{},
+			// reassembled transfers are handled by the caller's own
+			// transport, this one only answers those addressed to the ECU
+			transport: NewTransport(mid, s.sender, func(*J1587Message) {}),
+		}
+		s.ecus[mid] = ecu
+	}
+	ecu.parameters[pid] = p
+
+	return nil
+}
+
+func (s *Simulator) AddRule(rule SimulationRule) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.rules = append(s.rules, rule)
+}
+
+// Handle answers a received message. Replies are sent in the background as
+// Handle is called from the device's receive path.
+func (s *Simulator) Handle(message *J1587Message) {
+	s.mtx.Lock()
+	ecus := []*simulatedEcu{}
+	for _, ecu := range s.ecus {
+		ecus = append(ecus, ecu)
+	}
+	_, simulated := s.ecus[message.Mid]
+	rules := s.rules
+	s.mtx.Unlock()
+
+	for _, ecu := range ecus {
+		ecu.transport.Handle(message)
+	}
+
+	// never answer the simulated ECUs themselves, which could loop
+	if simulated {
+		return
+	}
+
+	parameters, _ := message.Parameters()
+	for _, p := range parameters {
+		switch p.Pid {
+		case 0, 256:
+			if len(p.Data) < 1 {
+				break
+			}
+			requested := p.Pid + int(p.Data[0])
+			for _, ecu := range ecus {
+				s.answer(ecu, message.Mid, requested)
+			}
+		case 128, 384:
+			if len(p.Data) < 2 {
+				break
+			}
+			requested := p.Pid - 128 + int(p.Data[0])
+			for _, ecu := range ecus {
+				if ecu.mid == int(p.Data[1]) {
+					s.answer(ecu, message.Mid, requested)
+				}
+			}
+		}
+
+		for _, rule := range rules {
+			if rule.Pid != p.Pid || (rule.From != AnyMid && rule.From != message.Mid) {
+				continue
+			}
+			// the adapter echoes what is sent, so a reply carrying the PID it
+			// answers from the MID it answers would trigger itself forever
+			if echoes(rule.Reply, message.Mid, p.Pid) {
+				continue
+			}
+			go s.send(rule.Reply)
+		}
+	}
+}
+
+// echoes reports whether a reply is sent from mid and carries pid.
+func echoes(reply []byte, mid int, pid int) bool {
+	if len(reply) < 1 || int(reply[0]) != mid {
+		return false
+	}
+
+	parameters, _ := ParseParameters(reply[1:])
+	for _, p := range parameters {
+		if p.Pid == pid {
+			return true
+		}
+	}
+	return false
+}
+
+// answer sends the ECU's stored value of a PID, if it has one, using
+// connection mode to the requester when it is too long for one message.
+func (s *Simulator) answer(ecu *simulatedEcu, requester int, pid int) {
+	s.mtx.Lock()
+	p, ok := ecu.parameters[pid]
+	s.mtx.Unlock()
+
+	if !ok {
+		return
+	}
+
+	// the MID and checksum take two bytes of the frame
+	if len(p)+2 <= J1708MaxLength {
+		go s.send(append([]byte{byte(ecu.mid)}, p...))
+		return
+	}
+
+	go func() {
+		err := ecu.transport.Send(requester, p)
+		if err != nil {
+			log.Printf("warn: simulated MID %d failed to send PID %d to MID %d: %v", ecu.mid, pid, requester, err)
+		}
+	}()
+}
+
+func (s *Simulator) send(message []byte) {
+	err := s.sender.Send(message)
+	if err != nil {
+		log.Printf("warn: simulator failed to send %v: %v", message, err)
+	}
+}
@@ -0,0 +1,154 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+)
+
+func handleRaw(t *testing.T, s *Simulator, raw ...byte) {
+	t.Helper()
+
+	m, err := ParseJ1587Message(raw)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	s.Handle(m)
+}
+
+func newTestSimulator(t *testing.T) (*Simulator, *fakeSender) {
+	sender := newFakeSender()
+	s := NewSimulator(sender)
+
+	for _, p := range []struct {
+		mid   int
+		pid   int
+		value string
+	}{
+		{130, 84, "100"},
+		{130, 259, "1"},
+		{136, 84, "50"},
+	} {
+		err := s.SetParameter(p.mid, p.pid, p.value)
+		if err != nil {
+			t.Fatalf("set parameter failed: %v", err)
+		}
+	}
+
+	return s, sender
+}
+
+func TestSimulatorAnswersRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		request []byte
+		answers [][]byte
+	}{
+		{"request", []byte{172, 0, 84}, [][]byte{{130, 84, 100}, {136, 84, 50}}},
+		{"component request", []byte{172, 128, 84, 136}, [][]byte{{136, 84, 50}}},
+		{"component request for another MID", []byte{172, 128, 84, 140}, [][]byte{}},
+		{"page 2 request", []byte{172, 255, 0, 3}, [][]byte{{130, 255, 3, 1}}},
+		{"page 2 component request", []byte{172, 255, 128, 3, 130}, [][]byte{{130, 255, 3, 1}}},
+		{"unknown parameter", []byte{172, 0, 190}, [][]byte{}},
+		{"from a simulated MID", []byte{130, 0, 84}, [][]byte{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, sender := newTestSimulator(t)
+
+			handleRaw(t, s, tt.request...)
+
+			got := [][]byte{}
+			for range tt.answers {
+				got = append(got, sender.next(t))
+			}
+			sender.none(t)
+
+			// answers are sent in the background so may arrive in any order
+			for _, want := range tt.answers {
+				found := false
+				for _, m := range got {
+					found = found || bytes.Equal(m, want)
+				}
+				if !found {
+					t.Errorf("expected answer %v got %v", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSimulatorRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    SimulationRule
+		message []byte
+		replied bool
+	}{
+		{"from the MID", SimulationRule{From: 196, Pid: 194, Reply: []byte{188, 84, 100}}, []byte{196, 194, 0}, true},
+		{"from another MID", SimulationRule{From: 196, Pid: 194, Reply: []byte{188, 84, 100}}, []byte{197, 194, 0}, false},
+		{"from any MID", SimulationRule{From: AnyMid, Pid: 194, Reply: []byte{188, 84, 100}}, []byte{197, 194, 0}, true},
+		{"another PID", SimulationRule{From: AnyMid, Pid: 194, Reply: []byte{188, 84, 100}}, []byte{196, 84, 0}, false},
+		{"any MID sending the reply's PID", SimulationRule{From: AnyMid, Pid: 84, Reply: []byte{188, 84, 100}}, []byte{128, 84, 90}, true},
+		{"echo of its own reply", SimulationRule{From: AnyMid, Pid: 84, Reply: []byte{188, 84, 100}}, []byte{188, 84, 100}, false},
+		{"echo of a reply with several PIDs", SimulationRule{From: AnyMid, Pid: 84, Reply: []byte{188, 190, 4, 8, 84, 100}}, []byte{188, 190, 4, 8, 84, 100}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := newFakeSender()
+			s := NewSimulator(sender)
+			s.AddRule(tt.rule)
+
+			handleRaw(t, s, tt.message...)
+
+			if !tt.replied {
+				sender.none(t)
+				return
+			}
+			if m := sender.next(t); !bytes.Equal(m, tt.rule.Reply) {
+				t.Errorf("expected reply %v got %v", tt.rule.Reply, m)
+			}
+			sender.none(t)
+		})
+	}
+}
+
+func TestSimulatorConnectionMode(t *testing.T) {
+	sender := newFakeSender()
+	s := NewSimulator(sender)
+
+	vin := "1FUJA6CK14LM94383 BUS 1234"
+	err := s.SetParameter(130, 237, "\""+vin+"\"")
+	if err != nil {
+		t.Fatalf("set parameter failed: %v", err)
+	}
+	body := append([]byte{237, byte(len(vin))}, vin...)
+
+	handleRaw(t, s, 172, 0, 237)
+
+	rts := sender.next(t)
+	if !bytes.Equal(rts, []byte{130, 197, 5, 172, TransportRequestToSend, 2, byte(len(body)), 0}) {
+		t.Fatalf("unexpected request to send %v", rts)
+	}
+
+	handleRaw(t, s, 172, 197, 4, 130, TransportClearToSend, 2, 1)
+
+	received := []byte{}
+	for segment := 1; segment <= 2; segment++ {
+		m := sender.next(t)
+		if len(m) < 5 || m[0] != 130 || m[1] != 198 || m[3] != 172 || int(m[4]) != segment {
+			t.Fatalf("unexpected segment %v", m)
+		}
+		if len(m)+1 > J1708MaxLength {
+			t.Errorf("segment %v is over the j1708 maximum length", m)
+		}
+		received = append(received, m[5:]...)
+	}
+	if !bytes.Equal(received, body) {
+		t.Errorf("expected segments of %v got %v", body, received)
+	}
+
+	handleRaw(t, s, 172, 197, 2, 130, TransportEndOfMessage)
+	sender.none(t)
+}
@@ -0,0 +1,366 @@
+package common
+
+import (
+	"encoding/binary"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Connection management control bytes of PID 197.
+const (
+	TransportRequestToSend = 1
+	TransportClearToSend   = 2
+	TransportEndOfMessage  = 3
+	TransportAbort         = 255
+)
+
+// transportSegmentSize keeps a PID 198 segment within the 21 byte j1708
+// limit: MID, PID, count, receiver MID, segment ID, data and checksum.
+const transportSegmentSize = 15
+
+const transportTimeout = 5 * time.Second
+
+// TransportControl is a decoded PID 197 connection management message.
+type TransportControl struct {
+	Receiver int
+	Control  int
+
+	// Segments is the total for a request to send and the number to send
+	// for a clear to send.
+	Segments int
+
+	// Bytes is the total message length of a request to send.
+	Bytes int
+
+	// NextSegment is the first segment a clear to send asks for.
+	NextSegment int
+}
+
+func (c *TransportControl) String() string {
+	switch c.Control {
+	case TransportRequestToSend:
+		return fmt.Sprintf("request to send MID %d %d bytes in %d segments", c.Receiver, c.Bytes, c.Segments)
+	case TransportClearToSend:
+		return fmt.Sprintf("clear to send MID %d %d segments from segment %d", c.Receiver, c.Segments, c.NextSegment)
+	case TransportEndOfMessage:
+		return fmt.Sprintf("end of message to MID %d", c.Receiver)
+	case TransportAbort:
+		return fmt.Sprintf("abort connection with MID %d", c.Receiver)
+	default:
+		return fmt.Sprintf("unknown control %d to MID %d", c.Control, c.Receiver)
+	}
+}
+
+// ParseTransportControl decodes PID 197 data, not including its byte count.
+func ParseTransportControl(data []byte) (*TransportControl, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("connection management expected length > '1' got '%d'", len(data))
+	}
+
+	c := &TransportControl{
+		Receiver: int(data[0]),
+		Control:  int(data[1]),
+	}
+
+	switch c.Control {
+	case TransportRequestToSend:
+		if len(data) < 5 {
+			return nil, fmt.Errorf("request to send expected length '5' got '%d'", len(data))
+		}
+		c.Segments = int(data[2])
+		c.Bytes = int(binary.LittleEndian.Uint16(data[3:]))
+		break
+	case TransportClearToSend:
+		if len(data) < 4 {
+			return nil, fmt.Errorf("clear to send expected length '4' got '%d'", len(data))
+		}
+		c.Segments = int(data[2])
+		c.NextSegment = int(data[3])
+		break
+	}
+
+	return c, nil
+}
+
+// TransportSegment is a decoded PID 198 connection mode data transfer.
+type TransportSegment struct {
+	Receiver int
+	Segment  int
+	Data     []byte
+}
+
+// ParseTransportSegment decodes PID 198 data, not including its byte count.
+func ParseTransportSegment(data []byte) (*TransportSegment, error) {
+	if len(data) < 2 {
+		return nil, fmt.Errorf("connection mode data expected length > '1' got '%d'", len(data))
+	}
+
+	return &TransportSegment{
+		Receiver: int(data[0]),
+		Segment:  int(data[1]),
+		Data:     data[2:],
+	}, nil
+}
+
+type transportKey struct {
+	sender   int
+	receiver int
+}
+
+type transportSession struct {
+	segments [][]byte
+	bytes    int
+	started  time.Time
+}
+
+func (s *transportSession) complete() bool {
+	for _, segment := range s.segments {
+		if segment == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *transportSession) data() []byte {
+	data := []byte{}
+	for _, segment := range s.segments {
+		data = append(data, segment...)
+	}
+	if len(data) > s.bytes {
+		data = data[:s.bytes]
+	}
+	return data
+}
+
+// Transport reassembles J1587 connection mode transfers (PIDs 197 and 198)
+// between any two MIDs on the bus, answering those addressed to the local
+// MID, and sends large messages from the local MID.
+type Transport struct {
+	localMid int
+	sender   Sender
+	handler  func(*J1587Message)
+
+	// timeout is how long a connection may wait on the other MID.
+	timeout time.Duration
+
+	mtx      *sync.Mutex
+	sessions map[transportKey]*transportSession
+	outbound map[int]chan *TransportControl
+}
+
+// NewTransport creates a transport that passes each reassembled message to
+// handler as if it had been received in one piece from its sender.
+func NewTransport(localMid int, sender Sender, handler func(*J1587Message)) *Transport {
+	return &Transport{
+		localMid: localMid,
+		sender:   sender,
+		handler:  handler,
+		timeout:  transportTimeout,
+		mtx:      new(sync.Mutex),
+		sessions: map[transportKey]*transportSession{},
+		outbound: map[int]chan *TransportControl{},
+	}
+}
+
+func (t *Transport) Handle(message *J1587Message) {
+	t.expire()
+
+	parameters, _ := message.Parameters()
+	for _, p := range parameters {
+		switch p.Pid {
+		case 197:
+			c, err := ParseTransportControl(p.Data)
+			if err != nil {
+				log.Printf("warn: %v", err)
+				continue
+			}
+			t.handleControl(message.Mid, c)
+			break
+		case 198:
+			s, err := ParseTransportSegment(p.Data)
+			if err != nil {
+				log.Printf("warn: %v", err)
+				continue
+			}
+			t.handleSegment(message, s)
+			break
+		}
+	}
+}
+
+func (t *Transport) handleControl(mid int, c *TransportControl) {
+	key := transportKey{mid, c.Receiver}
+
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if c.Receiver == t.localMid {
+		if waiting, ok := t.outbound[mid]; ok && c.Control != TransportRequestToSend {
+			select {
+			case waiting <- c:
+			default:
+			}
+			return
+		}
+	}
+
+	switch c.Control {
+	case TransportRequestToSend:
+		if c.Segments == 0 {
+			return
+		}
+		t.sessions[key] = &transportSession{
+			segments: make([][]byte, c.Segments),
+			bytes:    c.Bytes,
+			started:  time.Now(),
+		}
+
+		if c.Receiver == t.localMid {
+			go t.send([]byte{byte(t.localMid), 197, 4, byte(mid), TransportClearToSend, byte(c.Segments), 1})
+		}
+		break
+	case TransportAbort:
+		delete(t.sessions, key)
+		break
+	}
+}
+
+func (t *Transport) handleSegment(message *J1587Message, s *TransportSegment) {
+	mid := message.Mid
+	key := transportKey{mid, s.Receiver}
+
+	t.mtx.Lock()
+	session, ok := t.sessions[key]
+	if !ok {
+		t.mtx.Unlock()
+		return
+	}
+
+	if s.Segment < 1 || s.Segment > len(session.segments) {
+		t.mtx.Unlock()
+		log.Printf("warn: segment '%d' from MID '%d' expected between '1' and '%d'", s.Segment, mid, len(session.segments))
+		return
+	}
+
+	session.segments[s.Segment-1] = append([]byte{}, s.Data...)
+	if !session.complete() {
+		t.mtx.Unlock()
+		return
+	}
+
+	delete(t.sessions, key)
+	t.mtx.Unlock()
+
+	if s.Receiver == t.localMid {
+		go t.send([]byte{byte(t.localMid), 197, 2, byte(mid), TransportEndOfMessage})
+	}
+
+	raw := append([]byte{byte(mid)}, session.data()...)
+	m, err := ParseJ1587Message(raw)
+	if err != nil {
+		log.Printf("warn: reassembled message from MID '%d': %v", mid, err)
+		return
+	}
+	m.Received = message.Received
+	m.Sequence = message.Sequence
+
+	t.handler(m)
+}
+
+// expire drops transfers whose sender stopped before completing them, so a
+// MID that never sends again does not keep its transfer forever.
+func (t *Transport) expire() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	for key, session := range t.sessions {
+		if time.Since(session.started) > t.timeout {
+			delete(t.sessions, key)
+		}
+	}
+}
+
+// send is used from Handle, which runs on the device's receive path and so
+// must not wait for the send to be acknowledged.
+func (t *Transport) send(message []byte) {
+	err := t.sender.Send(message)
+	if err != nil {
+		log.Printf("warn: failed to send transport message: %v", err)
+	}
+}
+
+// Send transfers a message body, the PIDs and data following the MID, to the
+// target MID using connection mode, waiting for the target to accept each
+// group of segments.
+func (t *Transport) Send(target int, body []byte) error {
+	segments := (len(body) + transportSegmentSize - 1) / transportSegmentSize
+	if segments == 0 || segments > 255 || len(body) > 0xFFFF {
+		return fmt.Errorf("message length '%d' cannot be sent with connection mode", len(body))
+	}
+
+	controls := make(chan *TransportControl, 1)
+
+	t.mtx.Lock()
+	if _, ok := t.outbound[target]; ok {
+		t.mtx.Unlock()
+		return fmt.Errorf("a connection to MID '%d' is already open", target)
+	}
+	t.outbound[target] = controls
+	t.mtx.Unlock()
+
+	defer func() {
+		t.mtx.Lock()
+		delete(t.outbound, target)
+		t.mtx.Unlock()
+	}()
+
+	rts := []byte{byte(t.localMid), 197, 5, byte(target), TransportRequestToSend, byte(segments), byte(len(body)), byte(len(body) >> 8)}
+	err := t.sender.Send(rts)
+	if err != nil {
+		return errors.Wrap(err, "failed to send request to send")
+	}
+
+	for {
+		var c *TransportControl
+		select {
+		case c = <-controls:
+		case <-time.After(t.timeout):
+			t.send([]byte{byte(t.localMid), 197, 2, byte(target), TransportAbort})
+			return fmt.Errorf("MID '%d' did not respond within %v", target, t.timeout)
+		}
+
+		switch c.Control {
+		case TransportEndOfMessage:
+			return nil
+		case TransportAbort:
+			return fmt.Errorf("MID '%d' aborted the connection", target)
+		case TransportClearToSend:
+			for i := 0; i < c.Segments; i++ {
+				segment := c.NextSegment + i
+				if segment < 1 || segment > segments {
+					break
+				}
+
+				start := (segment - 1) * transportSegmentSize
+				end := start + transportSegmentSize
+				if end > len(body) {
+					end = len(body)
+				}
+
+				m := []byte{byte(t.localMid), 198, byte(end - start + 2), byte(target), byte(segment)}
+				m = append(m, body[start:end]...)
+
+				err = t.sender.Send(m)
+				if err != nil {
+					return errors.Wrapf(err, "failed to send segment '%d'", segment)
+				}
+			}
+			break
+		}
+	}
+}
@@ -0,0 +1,212 @@
+package common
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// transportBody is a VIN parameter long enough to take three segments.
+var transportBody = append([]byte{237, 30}, "1FUJA6CK14LM94383 BUS 12345678"...)
+
+func newTestTransport() (*Transport, *fakeSender, chan *J1587Message) {
+	sender := newFakeSender()
+	received := make(chan *J1587Message, 10)
+	t := NewTransport(172, sender, func(m *J1587Message) {
+		received <- m
+	})
+	return t, sender, received
+}
+
+func handleTransport(t *testing.T, transport *Transport, raw ...byte) {
+	t.Helper()
+
+	m, err := ParseJ1587Message(raw)
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	transport.Handle(m)
+}
+
+// transportSegment is segment n of transportBody from sender to receiver.
+func transportSegment(sender int, receiver int, n int) []byte {
+	start := (n - 1) * transportSegmentSize
+	end := start + transportSegmentSize
+	if end > len(transportBody) {
+		end = len(transportBody)
+	}
+
+	m := []byte{byte(sender), 198, byte(end - start + 2), byte(receiver), byte(n)}
+	return append(m, transportBody[start:end]...)
+}
+
+func TestTransportReassembles(t *testing.T) {
+	tests := []struct {
+		name     string
+		receiver int
+		segments []int
+	}{
+		{"in order", 172, []int{1, 2, 3}},
+		{"out of order", 172, []int{3, 1, 2}},
+		{"duplicates", 172, []int{1, 1, 2, 1, 3, 3}},
+		{"between other MIDs", 140, []int{2, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transport, sender, received := newTestTransport()
+			local := tt.receiver == 172
+
+			handleTransport(t, transport, 130, 197, 5, byte(tt.receiver), TransportRequestToSend, 3, byte(len(transportBody)), 0)
+			if local {
+				if m := sender.next(t); !bytes.Equal(m, []byte{172, 197, 4, 130, TransportClearToSend, 3, 1}) {
+					t.Fatalf("unexpected clear to send %v", m)
+				}
+			}
+
+			for _, n := range tt.segments {
+				handleTransport(t, transport, transportSegment(130, tt.receiver, n)...)
+			}
+
+			select {
+			case m := <-received:
+				if m.Mid != 130 || m.Pid != 237 || !bytes.Equal(m.Raw, append([]byte{130}, transportBody...)) {
+					t.Errorf("unexpected reassembled message %+v", m)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("nothing reassembled")
+			}
+			select {
+			case m := <-received:
+				t.Errorf("unexpected second message %+v", m)
+			default:
+			}
+
+			if local {
+				if m := sender.next(t); !bytes.Equal(m, []byte{172, 197, 2, 130, TransportEndOfMessage}) {
+					t.Errorf("unexpected end of message %v", m)
+				}
+			}
+			sender.none(t)
+		})
+	}
+}
+
+func TestTransportAbort(t *testing.T) {
+	transport, sender, received := newTestTransport()
+
+	handleTransport(t, transport, 130, 197, 5, 172, TransportRequestToSend, 3, byte(len(transportBody)), 0)
+	sender.next(t)
+
+	handleTransport(t, transport, transportSegment(130, 172, 1)...)
+	handleTransport(t, transport, 130, 197, 2, 172, TransportAbort)
+	handleTransport(t, transport, transportSegment(130, 172, 2)...)
+	handleTransport(t, transport, transportSegment(130, 172, 3)...)
+
+	select {
+	case m := <-received:
+		t.Errorf("unexpected message after abort %+v", m)
+	case <-time.After(100 * time.Millisecond):
+	}
+	sender.none(t)
+}
+
+func TestTransportExpiresStaleSessions(t *testing.T) {
+	transport, _, _ := newTestTransport()
+	transport.timeout = 50 * time.Millisecond
+
+	handleTransport(t, transport, 130, 197, 5, 140, TransportRequestToSend, 3, byte(len(transportBody)), 0)
+	handleTransport(t, transport, transportSegment(130, 140, 1)...)
+
+	time.Sleep(2 * transport.timeout)
+
+	// traffic between any MIDs expires the stalled transfer
+	handleTransport(t, transport, 128, 84, 100)
+
+	transport.mtx.Lock()
+	sessions := len(transport.sessions)
+	transport.mtx.Unlock()
+
+	if sessions != 0 {
+		t.Errorf("expected the stale session dropped got '%d' sessions", sessions)
+	}
+}
+
+func TestTransportSend(t *testing.T) {
+	transport, sender, _ := newTestTransport()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- transport.Send(130, transportBody)
+	}()
+
+	if m := sender.next(t); !bytes.Equal(m, []byte{172, 197, 5, 130, TransportRequestToSend, 3, byte(len(transportBody)), 0}) {
+		t.Fatalf("unexpected request to send %v", m)
+	}
+
+	// the receiver asks for the segments in two groups
+	handleTransport(t, transport, 130, 197, 4, 172, TransportClearToSend, 2, 1)
+	for n := 1; n <= 2; n++ {
+		if m := sender.next(t); !bytes.Equal(m, transportSegment(172, 130, n)) {
+			t.Fatalf("segment %d expected %v got %v", n, transportSegment(172, 130, n), m)
+		}
+	}
+	sender.none(t)
+
+	handleTransport(t, transport, 130, 197, 4, 172, TransportClearToSend, 1, 3)
+	if m := sender.next(t); !bytes.Equal(m, transportSegment(172, 130, 3)) {
+		t.Fatalf("segment 3 expected %v got %v", transportSegment(172, 130, 3), m)
+	}
+
+	handleTransport(t, transport, 130, 197, 2, 172, TransportEndOfMessage)
+
+	select {
+	case err := <-errs:
+		if err != nil {
+			t.Errorf("send failed: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("send did not finish")
+	}
+}
+
+func TestTransportSendAborted(t *testing.T) {
+	transport, sender, _ := newTestTransport()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- transport.Send(130, transportBody)
+	}()
+	sender.next(t)
+
+	handleTransport(t, transport, 130, 197, 2, 172, TransportAbort)
+
+	select {
+	case err := <-errs:
+		if err == nil {
+			t.Error("expected an error when the receiver aborts")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("send did not finish")
+	}
+}
+
+func TestTransportSendTimeout(t *testing.T) {
+	transport, sender, _ := newTestTransport()
+	transport.timeout = 50 * time.Millisecond
+
+	err := transport.Send(130, transportBody)
+	if err == nil {
+		t.Fatal("expected an error when the receiver does not respond")
+	}
+
+	sender.next(t)
+	if m := sender.next(t); !bytes.Equal(m, []byte{172, 197, 2, 130, TransportAbort}) {
+		t.Errorf("expected an abort got %v", m)
+	}
+
+	err = transport.Send(140, []byte{})
+	if err == nil {
+		t.Error("expected an error sending an empty body")
+	}
+}
@@ -2,69 +2,256 @@ package simma
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/syncromatics/j1708-tester/pkg/common"
 	"golang.org/x/sync/errgroup"
 )
 
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+type ConnectionState int
+
+const (
+	Disconnected ConnectionState = iota
+	Connected
+)
+
+func (s ConnectionState) String() string {
+	switch s {
+	case Connected:
+		return "connected"
+	default:
+		return "disconnected"
+	}
+}
+
+// Mode is how the device delivers the messages it receives.
+type Mode int
+
+const (
+	// J1587Mode parses each message as j1587 and passes it to the j1587
+	// handler.
+	J1587Mode Mode = iota
+
+	// J1708Mode passes each message unparsed to the j1708 handler, for
+	// debugging traffic that is not j1587 or is malformed.
+	J1708Mode
+)
+
+func (m Mode) String() string {
+	switch m {
+	case J1708Mode:
+		return "j1708"
+	default:
+		return "j1587"
+	}
+}
+
+// ParseMode parses the name of a mode as returned by String.
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "j1587":
+		return J1587Mode, nil
+	case "j1708":
+		return J1708Mode, nil
+	default:
+		return J1587Mode, fmt.Errorf("unknown mode '%s', expected j1587 or j1708", s)
+	}
+}
+
 type Device struct {
-	port string
+	port      string
+	open      func() (io.ReadWriteCloser, error)
+	reconnect bool
+
+	passAllMode PassAllMode
+	mode        Mode
 
+	mtx          *sync.Mutex
 	j1587Handler func(*common.J1587Message)
+	j1708Handler func(*common.J1708Frame)
+	stateHandler func(ConnectionState)
 	protocol     *protocol
 }
 
 func NewDevice(port string, j1587Handler func(*common.J1587Message)) *Device {
+	return NewSerialDevice(DefaultSerialConfig(port), j1587Handler)
+}
+
+func NewSerialDevice(config SerialConfig, j1587Handler func(*common.J1587Message)) *Device {
 	return &Device{
-		port:         port,
+		port: config.Port,
+		open: func() (io.ReadWriteCloser, error) {
+			return openSerialPort(config)
+		},
+		reconnect:    true,
+		passAllMode:  DefaultPassAllMode(),
+		mtx:          new(sync.Mutex),
 		j1587Handler: j1587Handler,
 	}
 }
 
+// NewDeviceWithTransport creates a device that talks to the adapter over an
+// already opened transport, such as a TCP socket, a pty or an in-memory pipe,
+// instead of a serial port. The name is only used in error messages. The
+// transport cannot be reopened, so the device does not reconnect.
+func NewDeviceWithTransport(name string, transport io.ReadWriteCloser, j1587Handler func(*common.J1587Message)) *Device {
+	return &Device{
+		port: name,
+		open: func() (io.ReadWriteCloser, error) {
+			return transport, nil
+		},
+		passAllMode:  DefaultPassAllMode(),
+		mtx:          new(sync.Mutex),
+		j1587Handler: j1587Handler,
+	}
+}
+
+// SetStateHandler sets a handler called whenever the device connects to or
+// disconnects from the adapter.
+func (d *Device) SetStateHandler(stateHandler func(ConnectionState)) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.stateHandler = stateHandler
+}
+
+// SetPassAllMode sets the pass all mode sent to the adapter on the next
+// connect.
+func (d *Device) SetPassAllMode(mode PassAllMode) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.passAllMode = mode
+}
+
+// SetJ1708Handler sets the handler that receives messages in J1708Mode.
+func (d *Device) SetJ1708Handler(j1708Handler func(*common.J1708Frame)) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.j1708Handler = j1708Handler
+}
+
+// SetMode sets how messages are delivered from the next connect.
+func (d *Device) SetMode(mode Mode) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.mode = mode
+}
+
+// Open connects to the adapter and, for serial devices, keeps reconnecting
+// with backoff until the context is cancelled.
 func (d *Device) Open(ctx context.Context) func() error {
+	return func() error {
+		delay := minReconnectDelay
+
+		for {
+			connected, err := d.connect(ctx)
+			if ctx.Err() != nil {
+				return nil
+			}
+			if !d.reconnect {
+				return err
+			}
+
+			if connected {
+				delay = minReconnectDelay
+			}
+
+			log.Printf("warn: device %s: %v, reconnecting in %v", d.port, err, delay)
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(delay):
+			}
+
+			delay *= 2
+			if delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+		}
+	}
+}
+
+func (d *Device) connect(ctx context.Context) (bool, error) {
+	port, err := d.open()
+	if err != nil {
+		return false, errors.Wrap(err, "failed opening transport")
+	}
+
 	cc, cancel := context.WithCancel(ctx)
+	defer cancel()
 	grp, cc := errgroup.WithContext(cc)
 
-	return func() error {
-		p, err := newProtocol(d.port, d.handleJ1587)
-		if err != nil {
-			return errors.Wrap(err, "Failed creating protocol")
-		}
-		d.protocol = p
-
-		grp.Go(p.Start(cc))
-
-		err = p.Send(passAllModeConfig{
-			port:  0,
-			j1587: false,
-			j1708: true,
-			j1939: false,
-			can:   false,
-		})
-		if err != nil {
-			return errors.Wrap(err, "failed to enabled pass all mode")
-		}
+	p := newProtocol(d.port, port, d.handleJ1587)
 
-		select {
-		case <-ctx.Done():
-		case <-cc.Done():
-			break
-		}
+	d.mtx.Lock()
+	mode := d.passAllMode
+	if d.mode == J1708Mode {
+		p.j1708Handler = d.handleJ1708
+	}
+	d.mtx.Unlock()
+
+	grp.Go(p.Start(cc))
 
+	err = p.Send(newPassAllModeConfig(mode))
+	if err != nil {
 		cancel()
-		return grp.Wait()
+		grp.Wait()
+		return false, errors.Wrap(err, "failed to enabled pass all mode")
+	}
+
+	d.setProtocol(p, Connected)
+	defer d.setProtocol(nil, Disconnected)
+
+	err = grp.Wait()
+	if err == nil {
+		err = fmt.Errorf("connection closed")
+	}
+	return true, err
+}
+
+func (d *Device) setProtocol(p *protocol, state ConnectionState) {
+	d.mtx.Lock()
+	d.protocol = p
+	stateHandler := d.stateHandler
+	d.mtx.Unlock()
+
+	if stateHandler != nil {
+		stateHandler(state)
 	}
 }
 
 func (d *Device) Send(message []byte) error {
-	mid := int(message[0])
-	pid := int(message[1])
+	d.mtx.Lock()
+	p := d.protocol
+	d.mtx.Unlock()
+
+	if p == nil {
+		return fmt.Errorf("device '%s' is not connected", d.port)
+	}
 
-	err := d.protocol.Send(&j1587Message{
-		Mid:  mid,
-		Pid:  pid,
-		Data: message[2:],
+	m, err := common.ParseJ1587Message(message)
+	if err != nil {
+		return errors.Wrap(err, "failed to parse j1587 message")
+	}
+
+	err = p.Send(&j1587Message{
+		Mid:  m.Mid,
+		Pid:  m.Pid,
+		Data: m.Data,
 	})
 	if err != nil {
 		return errors.Wrap(err, "failed to send j1587 message")
@@ -74,9 +261,27 @@ func (d *Device) Send(message []byte) error {
 
 func (d *Device) handleJ1587(m *j1587Message) {
 	d.j1587Handler(&common.J1587Message{
-		Mid:  m.Mid,
-		Pid:  m.Pid,
-		Data: m.Data,
-		Raw:  m.Raw,
+		Mid:      m.Mid,
+		Pid:      m.Pid,
+		Data:     m.Data,
+		Raw:      m.Raw,
+		Received: m.Received,
+		Sequence: m.Sequence,
 	})
 }
+
+func (d *Device) handleJ1708(f *j1708Frame) {
+	d.mtx.Lock()
+	j1708Handler := d.j1708Handler
+	d.mtx.Unlock()
+
+	if j1708Handler == nil {
+		return
+	}
+
+	frame := common.NewJ1708Frame(f.Mid, f.Data)
+	frame.Received = f.Received
+	frame.Sequence = f.Sequence
+
+	j1708Handler(frame)
+}
@@ -0,0 +1,112 @@
+package simma
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/syncromatics/j1708-tester/pkg/common"
+	"github.com/syncromatics/j1708-tester/pkg/simma/fake"
+)
+
+// openFakeDevice connects a device to a fake adapter and waits for it to be
+// connected.
+func openFakeDevice(t *testing.T, handler func(*common.J1587Message)) (*Device, *fake.Adapter) {
+	adapter := fake.NewAdapter()
+	adapter.StatsInterval = 0
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go adapter.Run(ctx)()
+
+	connected := make(chan struct{}, 1)
+	d := NewDeviceWithTransport("fake", adapter.Transport(), handler)
+	d.SetStateHandler(func(s ConnectionState) {
+		if s == Connected {
+			connected <- struct{}{}
+		}
+	})
+	go d.Open(ctx)()
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("device did not connect")
+	}
+
+	return d, adapter
+}
+
+func TestDeviceSendAcked(t *testing.T) {
+	d, adapter := openFakeDevice(t, func(*common.J1587Message) {})
+
+	err := d.Send([]byte{172, 128, 194, 196})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	select {
+	case tr := <-adapter.Transmitted():
+		if tr.Mid != 172 || tr.Pid != 128 || string(tr.Data) != string([]byte{194, 196}) {
+			t.Errorf("unexpected transmission %+v", tr)
+		}
+	default:
+		t.Fatal("nothing transmitted")
+	}
+}
+
+func TestDeviceSendAckTimeout(t *testing.T) {
+	timeout := ackTimeout
+	ackTimeout = 50 * time.Millisecond
+	defer func() {
+		ackTimeout = timeout
+	}()
+
+	d, adapter := openFakeDevice(t, func(*common.J1587Message) {})
+	adapter.DropAcks(sendRetries)
+
+	err := d.Send([]byte{172, 128, 194, 196})
+	if !IsAckTimeout(err) {
+		t.Fatalf("expected an ack timeout got %v", err)
+	}
+
+	err = d.Send([]byte{172, 128, 194, 196})
+	if err != nil {
+		t.Fatalf("send after the dropped acks failed: %v", err)
+	}
+}
+
+func TestDeviceSequenceCountsBusMessages(t *testing.T) {
+	received := make(chan *common.J1587Message, 2)
+	d, adapter := openFakeDevice(t, func(m *common.J1587Message) {
+		received <- m
+	})
+
+	err := d.Send([]byte{172, 128, 194, 196})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+	err = adapter.SendStats()
+	if err != nil {
+		t.Fatalf("sending stats failed: %v", err)
+	}
+
+	for _, mid := range []int{128, 130} {
+		err = adapter.InjectJ1587(mid, 84, []byte{100})
+		if err != nil {
+			t.Fatalf("inject failed: %v", err)
+		}
+	}
+
+	for want := uint64(1); want <= 2; want++ {
+		select {
+		case m := <-received:
+			if m.Sequence != want {
+				t.Errorf("MID %d expected sequence '%d' got '%d'", m.Mid, want, m.Sequence)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("nothing received")
+		}
+	}
+}
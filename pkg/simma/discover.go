@@ -0,0 +1,138 @@
+package simma
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+type AdapterInfo struct {
+	Port            string
+	HardwareVersion int
+	SoftwareVersion int
+}
+
+// CandidatePorts lists the serial ports an adapter might be attached to.
+// Ports reachable through several paths, such as /dev/serial/by-id links,
+// are only listed once under their first path.
+func CandidatePorts() ([]string, error) {
+	patterns := []string{}
+	switch runtime.GOOS {
+	case "windows":
+		ports := []string{}
+		for i := 1; i <= 32; i++ {
+			ports = append(ports, fmt.Sprintf("COM%d", i))
+		}
+		return ports, nil
+	case "darwin":
+		patterns = []string{"/dev/serial/by-id/*", "/dev/tty.usb*", "/dev/cu.usb*"}
+		break
+	default:
+		patterns = []string{"/dev/serial/by-id/*", "/dev/ttyUSB*", "/dev/ttyACM*", "/dev/ttyS*"}
+		break
+	}
+
+	seen := map[string]bool{}
+	ports := []string{}
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed listing '%s'", pattern)
+		}
+		sort.Strings(matches)
+
+		for _, m := range matches {
+			target, err := filepath.EvalSymlinks(m)
+			if err != nil {
+				target = m
+			}
+			if seen[target] {
+				continue
+			}
+			seen[target] = true
+			ports = append(ports, m)
+		}
+	}
+
+	return ports, nil
+}
+
+// Probe opens the port, asks for the adapter's stats and returns its versions.
+func Probe(config SerialConfig, timeout time.Duration) (*AdapterInfo, error) {
+	port, err := openSerialPort(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return ProbeTransport(config.Port, port, timeout)
+}
+
+// ProbeTransport is Probe over an already opened transport. The transport is
+// closed when it returns.
+func ProbeTransport(name string, transport io.ReadWriteCloser, timeout time.Duration) (*AdapterInfo, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := newProtocol(name, transport, func(*j1587Message) {})
+	go p.Start(ctx)()
+
+	s, err := p.RequestStats(timeout)
+	if err != nil {
+		return nil, errors.Wrapf(err, "no adapter on '%s'", name)
+	}
+
+	return &AdapterInfo{
+		Port:            name,
+		HardwareVersion: s.HardwareVersion,
+		SoftwareVersion: s.SoftwareVersion,
+	}, nil
+}
+
+// Discover probes every candidate port with the given serial settings and
+// returns the adapters that answered.
+func Discover(config SerialConfig, timeout time.Duration) ([]AdapterInfo, error) {
+	ports, err := CandidatePorts()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed listing ports")
+	}
+
+	mtx := new(sync.Mutex)
+	found := map[string]AdapterInfo{}
+
+	wg := new(sync.WaitGroup)
+	for _, port := range ports {
+		c := config
+		c.Port = port
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			info, err := Probe(c, timeout)
+			if err != nil {
+				return
+			}
+
+			mtx.Lock()
+			found[info.Port] = *info
+			mtx.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	adapters := []AdapterInfo{}
+	for _, port := range ports {
+		if info, ok := found[port]; ok {
+			adapters = append(adapters, info)
+		}
+	}
+
+	return adapters, nil
+}
@@ -0,0 +1,80 @@
+package simma
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/syncromatics/j1708-tester/pkg/simma/fake"
+)
+
+func runFakeAdapter(t *testing.T, adapter *fake.Adapter) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	go adapter.Run(ctx)()
+}
+
+func TestProbeTransport(t *testing.T) {
+	tests := []struct {
+		name   string
+		ignore bool
+	}{
+		{"answered request", false},
+		{"periodic stats only", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			adapter := fake.NewAdapter()
+			adapter.HardwareVersion = 3
+			adapter.SoftwareVersion = 7
+			adapter.StatsInterval = 100 * time.Millisecond
+			adapter.IgnoreStatsRequests = tt.ignore
+			runFakeAdapter(t, adapter)
+
+			info, err := ProbeTransport("fake", adapter.Transport(), time.Second)
+			if err != nil {
+				t.Fatalf("probe failed: %v", err)
+			}
+			if info.Port != "fake" || info.HardwareVersion != 3 || info.SoftwareVersion != 7 {
+				t.Errorf("unexpected adapter %+v", info)
+			}
+		})
+	}
+}
+
+func TestProbeTransportTimeout(t *testing.T) {
+	host, other := net.Pipe()
+	defer other.Close()
+
+	// a device that reads everything and never answers
+	go io.Copy(ioutil.Discard, other)
+
+	start := time.Now()
+	_, err := ProbeTransport("silent", host, 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected an error from a port that never answers")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("probe took '%v' with a '100ms' timeout", elapsed)
+	}
+}
+
+func TestNewStats(t *testing.T) {
+	s, err := newStats([]byte{23, 0, 0, 1, 0, 0, 0, 0, 2, 0, 0, 0, 3, 4, 5})
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	if s.TotalValidJ1708Messages != 256 || s.TotalInvalidJ1708Bytes != 2 || s.TotalCANFrames != 3 || s.HardwareVersion != 4 || s.SoftwareVersion != 5 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+
+	_, err = newStats([]byte{23, 0, 0})
+	if err == nil {
+		t.Error("expected an error for a short stats message")
+	}
+}
@@ -0,0 +1,313 @@
+// Package fake implements the adapter side of the Simma VNA2-USB serial
+// protocol so the simma package can be driven without hardware attached.
+package fake
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/j1708-tester/pkg/simma/frame"
+)
+
+const (
+	ackMessage         = 0
+	j1587SendMessage   = 8
+	passAllModeMessage = 18
+	j1587Message       = 22
+	statsMessage       = 23
+)
+
+type PassAllMode struct {
+	Port  int
+	J1708 bool
+	J1587 bool
+	CAN   bool
+	J1939 bool
+}
+
+// Transmission is a j1587 message the host asked the adapter to put on the bus,
+// with its fields as the host sent them. A page 2 PID is sent as PID 255 with
+// the PID's own byte leading the data.
+type Transmission struct {
+	Mid      int
+	Pid      int
+	Priority int
+	Data     []byte
+}
+
+// Frame is the transmission as it appears on the bus, without its checksum:
+// the MID, the PID's low byte and the data.
+func (t *Transmission) Frame() []byte {
+	return append([]byte{byte(t.Mid), byte(t.Pid)}, t.Data...)
+}
+
+type Adapter struct {
+	HardwareVersion int
+	SoftwareVersion int
+	StatsInterval   time.Duration
+
+	// IgnoreStatsRequests acks stats requests without answering them, so
+	// stats only arrive every StatsInterval.
+	IgnoreStatsRequests bool
+
+	host net.Conn
+	conn net.Conn
+
+	writeMtx *sync.Mutex
+	encoder  *frame.Encoder
+
+	mtx                *sync.Mutex
+	passAll            PassAllMode
+	dropAcks           int
+	validJ1708Messages int
+	invalidJ1708Bytes  int
+
+	transmitted chan *Transmission
+}
+
+// NewAdapter creates an adapter connected to one end of an in-memory pipe.
+// Hand Transport() to simma.NewDeviceWithTransport and start Run.
+func NewAdapter() *Adapter {
+	host, conn := net.Pipe()
+
+	return &Adapter{
+		HardwareVersion: 2,
+		SoftwareVersion: 1,
+		StatsInterval:   time.Second,
+		host:            host,
+		conn:            conn,
+		mtx:             new(sync.Mutex),
+		writeMtx:        new(sync.Mutex),
+		encoder:         frame.NewEncoder(conn),
+		transmitted:     make(chan *Transmission, 256),
+	}
+}
+
+// Transport is the host end of the pipe.
+func (a *Adapter) Transport() io.ReadWriteCloser {
+	return a.host
+}
+
+// Transmitted receives every j1587 message the host sent and the adapter acked.
+func (a *Adapter) Transmitted() <-chan *Transmission {
+	return a.transmitted
+}
+
+func (a *Adapter) PassAllMode() PassAllMode {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	return a.passAll
+}
+
+// DropAcks makes the adapter silently ignore the next n messages from the
+// host, so the host's retry logic can be exercised.
+func (a *Adapter) DropAcks(n int) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.dropAcks = n
+}
+
+// AddInvalidBytes bumps the invalid j1708 byte count reported in stats.
+func (a *Adapter) AddInvalidBytes(n int) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	a.invalidJ1708Bytes += n
+}
+
+// InjectJ1587 sends a j1587 message to the host as if it had been received
+// from the bus. Pids above 255 are sent with the page 2 extension prefix.
+func (a *Adapter) InjectJ1587(mid int, pid int, data []byte) error {
+	a.mtx.Lock()
+	enabled := a.passAll.J1708 || a.passAll.J1587
+	if enabled {
+		a.validJ1708Messages++
+	}
+	a.mtx.Unlock()
+
+	if !enabled {
+		return fmt.Errorf("pass all mode is not enabled")
+	}
+
+	m := []byte{j1587Message, byte(mid)}
+	if pid > 255 {
+		m = append(m, 255, byte(pid-256))
+	} else {
+		m = append(m, byte(pid))
+	}
+	m = append(m, data...)
+
+	return a.write(m)
+}
+
+// InjectJ1708 sends any bytes to the host as if they were a message received
+// from the bus, for testing malformed or non j1587 traffic.
+func (a *Adapter) InjectJ1708(mid int, data []byte) error {
+	a.mtx.Lock()
+	enabled := a.passAll.J1708
+	if enabled {
+		a.validJ1708Messages++
+	}
+	a.mtx.Unlock()
+
+	if !enabled {
+		return fmt.Errorf("j1708 pass all mode is not enabled")
+	}
+
+	m := append([]byte{j1587Message, byte(mid)}, data...)
+
+	return a.write(m)
+}
+
+// SendStats sends a stats message to the host immediately.
+func (a *Adapter) SendStats() error {
+	a.mtx.Lock()
+	m := make([]byte, 15)
+	m[0] = statsMessage
+	binary.BigEndian.PutUint32(m[1:], uint32(a.validJ1708Messages))
+	binary.BigEndian.PutUint32(m[5:], uint32(a.invalidJ1708Bytes))
+	binary.BigEndian.PutUint32(m[9:], 0)
+	m[13] = byte(a.HardwareVersion)
+	m[14] = byte(a.SoftwareVersion)
+	a.mtx.Unlock()
+
+	return a.write(m)
+}
+
+func (a *Adapter) Run(ctx context.Context) func() error {
+	return func() error {
+		done := make(chan struct{})
+		defer close(done)
+
+		go func() {
+			select {
+			case <-ctx.Done():
+			case <-done:
+			}
+			a.conn.Close()
+		}()
+
+		if a.StatsInterval > 0 {
+			go a.sendStats(done)
+		}
+
+		err := a.read()
+		if ctx.Err() != nil {
+			return nil
+		}
+		return err
+	}
+}
+
+func (a *Adapter) sendStats(done chan struct{}) {
+	ticker := time.NewTicker(a.StatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			a.SendStats()
+		}
+	}
+}
+
+func (a *Adapter) handle(message []byte) error {
+	a.mtx.Lock()
+	if a.dropAcks > 0 {
+		a.dropAcks--
+		a.mtx.Unlock()
+		return nil
+	}
+	a.mtx.Unlock()
+
+	switch message[0] {
+	case passAllModeMessage:
+		if len(message) != 6 {
+			return nil
+		}
+
+		a.mtx.Lock()
+		a.passAll = PassAllMode{
+			Port:  int(message[1]),
+			J1708: message[2] == 1,
+			J1587: message[3] == 1,
+			CAN:   message[4] == 1,
+			J1939: message[5] == 1,
+		}
+		a.mtx.Unlock()
+		break
+	case j1587SendMessage:
+		if len(message) < 5 {
+			return nil
+		}
+
+		t := &Transmission{
+			Mid:      int(message[1]),
+			Pid:      int(message[2])<<8 | int(message[3]),
+			Priority: int(message[4]),
+			Data:     append([]byte{}, message[5:]...),
+		}
+
+		select {
+		case a.transmitted <- t:
+		default:
+		}
+		break
+	case statsMessage:
+		err := a.write([]byte{ackMessage, message[0]})
+		if err != nil {
+			return err
+		}
+		if a.IgnoreStatsRequests {
+			return nil
+		}
+		return a.SendStats()
+	}
+
+	return a.write([]byte{ackMessage, message[0]})
+}
+
+func (a *Adapter) read() error {
+	decoder := frame.NewDecoder(a.conn)
+
+	for {
+		m, err := decoder.Decode()
+		if frame.IsFrameError(err) {
+			continue
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed reading from host")
+		}
+
+		if len(m) == 0 {
+			continue
+		}
+
+		err = a.handle(m)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (a *Adapter) write(message []byte) error {
+	a.writeMtx.Lock()
+	defer a.writeMtx.Unlock()
+
+	err := a.encoder.Encode(message)
+	if err != nil {
+		return errors.Wrap(err, "failed writing to host")
+	}
+
+	return nil
+}
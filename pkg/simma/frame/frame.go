@@ -0,0 +1,236 @@
+// Package frame implements the serial framing used by the Simma VNA
+// adapters. A frame is a start byte, a two byte big endian length that counts
+// the message and the checksum, the message and a checksum byte that makes the
+// length and message bytes sum to zero. Start and escape bytes inside a frame
+// are escaped.
+package frame
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+const (
+	Start         = 192
+	Escape        = 219
+	EscapedStart  = 220
+	EscapedEscape = 221
+
+	// MaxLength is the largest message a frame may carry.
+	MaxLength = 1024
+)
+
+// EscapeError is returned when an escape byte is followed by anything other
+// than an escaped start or escape byte. The frame is discarded.
+type EscapeError struct {
+	Byte byte
+}
+
+func (e *EscapeError) Error() string {
+	return fmt.Sprintf("bad escape sequence '%d %d'", Escape, e.Byte)
+}
+
+// LengthError is returned when a frame's message length is outside of
+// 0 to MaxLength.
+type LengthError struct {
+	Length int
+}
+
+func (e *LengthError) Error() string {
+	return fmt.Sprintf("frame length '%d' expected between '0' and '%d'", e.Length, MaxLength)
+}
+
+// ChecksumError is returned when a frame's checksum does not match its
+// contents.
+type ChecksumError struct {
+	Expected byte
+	Actual   byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("frame checksum '%d' expected '%d'", e.Actual, e.Expected)
+}
+
+// IsFrameError reports whether err is one of the frame errors above, after
+// which decoding can continue, rather than an error from the reader.
+func IsFrameError(err error) bool {
+	switch err.(type) {
+	case *EscapeError, *LengthError, *ChecksumError:
+		return true
+	}
+	return false
+}
+
+// errStart signals a start byte in the middle of a frame, which always
+// begins a new frame.
+var errStart = fmt.Errorf("unexpected start byte")
+
+func Checksum(message []byte) byte {
+	l := len(message) + 1
+	cs := byte(l>>8) + byte(l)
+	for _, b := range message {
+		cs += b
+	}
+	return -cs
+}
+
+type Decoder struct {
+	r       io.ByteReader
+	started bool
+}
+
+func NewDecoder(r io.Reader) *Decoder {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = bufio.NewReader(r)
+	}
+
+	return &Decoder{r: br}
+}
+
+// Decode reads the next frame and returns its message. Bytes before a start
+// byte are skipped. After a frame error the next call resumes with the
+// following frame; any other error comes from the underlying reader.
+func (d *Decoder) Decode() ([]byte, error) {
+	for {
+		if !d.started {
+			b, err := d.r.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			if b != Start {
+				continue
+			}
+		}
+		d.started = false
+
+		m, err := d.decodeFrame()
+		if err == errStart {
+			d.started = true
+			continue
+		}
+		return m, err
+	}
+}
+
+func (d *Decoder) decodeFrame() ([]byte, error) {
+	hi, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+	lo, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	length := int(hi)<<8 + int(lo) - 1
+	if length < 0 || length > MaxLength {
+		return nil, &LengthError{length}
+	}
+
+	cs := hi + lo
+
+	m := make([]byte, length)
+	for i := range m {
+		m[i], err = d.readByte()
+		if err != nil {
+			return nil, err
+		}
+		cs += m[i]
+	}
+
+	c, err := d.readByte()
+	if err != nil {
+		return nil, err
+	}
+
+	if c != -cs {
+		return nil, &ChecksumError{Expected: -cs, Actual: c}
+	}
+
+	return m, nil
+}
+
+func (d *Decoder) readByte() (byte, error) {
+	b, err := d.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+
+	switch b {
+	case Start:
+		return 0, errStart
+	case Escape:
+		b, err = d.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case EscapedStart:
+			return Start, nil
+		case EscapedEscape:
+			return Escape, nil
+		case Start:
+			return 0, errStart
+		default:
+			return 0, &EscapeError{b}
+		}
+	}
+
+	return b, nil
+}
+
+type Encoder struct {
+	w      io.Writer
+	buffer []byte
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{
+		w:      w,
+		buffer: make([]byte, 0, 2*MaxLength+7),
+	}
+}
+
+// Encode writes message as a single frame with one call to the underlying
+// writer. It is not safe for concurrent use.
+func (e *Encoder) Encode(message []byte) error {
+	if len(message) > MaxLength {
+		return &LengthError{len(message)}
+	}
+
+	l := len(message) + 1
+
+	e.buffer = append(e.buffer[:0], Start)
+	e.writeByte(byte(l >> 8))
+	e.writeByte(byte(l))
+
+	for _, b := range message {
+		e.writeByte(b)
+	}
+
+	e.writeByte(Checksum(message))
+
+	n, err := e.w.Write(e.buffer)
+	if err != nil {
+		return err
+	}
+	if n != len(e.buffer) {
+		return io.ErrShortWrite
+	}
+
+	return nil
+}
+
+func (e *Encoder) writeByte(b byte) {
+	switch b {
+	case Start:
+		e.buffer = append(e.buffer, Escape, EscapedStart)
+	case Escape:
+		e.buffer = append(e.buffer, Escape, EscapedEscape)
+	default:
+		e.buffer = append(e.buffer, b)
+	}
+}
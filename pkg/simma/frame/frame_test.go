@@ -0,0 +1,161 @@
+package frame
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+func encode(t *testing.T, messages ...[]byte) *bytes.Buffer {
+	b := new(bytes.Buffer)
+	e := NewEncoder(b)
+	for _, m := range messages {
+		err := e.Encode(m)
+		if err != nil {
+			t.Fatalf("encode %v failed: %v", m, err)
+		}
+	}
+	return b
+}
+
+func TestRoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		message []byte
+	}{
+		{"empty", []byte{}},
+		{"ack", []byte{0, 8}},
+		{"start byte", []byte{22, Start, 84}},
+		{"escape byte", []byte{22, Escape, 84}},
+		{"escaped values", []byte{Start, Escape, EscapedStart, EscapedEscape}},
+		{"max length", bytes.Repeat([]byte{Start}, MaxLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := encode(t, tt.message)
+			if bytes.Count(b.Bytes(), []byte{Start}) != 1 {
+				t.Fatalf("encoded frame %v has an unescaped start byte", b.Bytes())
+			}
+
+			m, err := NewDecoder(b).Decode()
+			if err != nil {
+				t.Fatalf("decode failed: %v", err)
+			}
+			if !bytes.Equal(m, tt.message) {
+				t.Errorf("decoded %v expected %v", m, tt.message)
+			}
+		})
+	}
+}
+
+func TestEncodeTooLong(t *testing.T) {
+	err := NewEncoder(new(bytes.Buffer)).Encode(make([]byte, MaxLength+1))
+	if _, ok := err.(*LengthError); !ok {
+		t.Errorf("expected a length error got %v", err)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame []byte
+		check func(error) bool
+	}{
+		{
+			name:  "escape",
+			frame: []byte{Start, 0, 2, Escape, 1, 0},
+			check: func(err error) bool {
+				e, ok := err.(*EscapeError)
+				return ok && e.Byte == 1
+			},
+		},
+		{
+			name:  "length",
+			frame: []byte{Start, 0, 0, 0},
+			check: func(err error) bool {
+				e, ok := err.(*LengthError)
+				return ok && e.Length == -1
+			},
+		},
+		{
+			name:  "checksum",
+			frame: []byte{Start, 0, 2, 8, 1},
+			check: func(err error) bool {
+				e, ok := err.(*ChecksumError)
+				return ok && e.Expected == Checksum([]byte{8}) && e.Actual == 1
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// a good frame follows to check decoding carries on
+			b := bytes.NewBuffer(tt.frame)
+			b.Write(encode(t, []byte{0, 8}).Bytes())
+
+			d := NewDecoder(b)
+
+			_, err := d.Decode()
+			if !tt.check(err) {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !IsFrameError(err) {
+				t.Errorf("expected %v to be a frame error", err)
+			}
+
+			m, err := d.Decode()
+			if err != nil || !bytes.Equal(m, []byte{0, 8}) {
+				t.Errorf("decode after error got %v, %v", m, err)
+			}
+		})
+	}
+}
+
+func TestDecodeResyncsOnStart(t *testing.T) {
+	b := bytes.NewBuffer([]byte{1, 2, Start, 0, 5, 22, 128})
+	b.Write(encode(t, []byte{22, 128, 84, 100}).Bytes())
+
+	d := NewDecoder(b)
+
+	m, err := d.Decode()
+	if err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if !bytes.Equal(m, []byte{22, 128, 84, 100}) {
+		t.Errorf("decoded %v after a cut off frame", m)
+	}
+
+	_, err = d.Decode()
+	if err != io.EOF {
+		t.Errorf("expected EOF got %v", err)
+	}
+}
+
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{Start, 0, 3, 0, 8, 245})
+	f.Add([]byte{Start, 0, 2, Escape, EscapedStart, 62})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		d := NewDecoder(bytes.NewReader(data))
+		for {
+			m, err := d.Decode()
+			if err != nil && !IsFrameError(err) {
+				return
+			}
+			if err != nil {
+				continue
+			}
+
+			b := new(bytes.Buffer)
+			err = NewEncoder(b).Encode(m)
+			if err != nil {
+				t.Fatalf("decoded message %v does not encode: %v", m, err)
+			}
+			r, err := NewDecoder(b).Decode()
+			if err != nil || !bytes.Equal(r, m) {
+				t.Fatalf("round trip of %v got %v, %v", m, r, err)
+			}
+		}
+	})
+}
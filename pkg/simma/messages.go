@@ -3,8 +3,29 @@ package simma
 import (
 	"encoding/binary"
 	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/syncromatics/j1708-tester/pkg/common"
 )
 
+// PassAllMode selects which port and protocols the adapter passes through
+// to the host.
+type PassAllMode struct {
+	Port  int
+	J1708 bool
+	J1587 bool
+	CAN   bool
+	J1939 bool
+}
+
+func DefaultPassAllMode() PassAllMode {
+	return PassAllMode{
+		Port:  0,
+		J1708: true,
+	}
+}
+
 type passAllModeConfig struct {
 	port  byte
 	j1708 bool
@@ -13,6 +34,16 @@ type passAllModeConfig struct {
 	j1939 bool
 }
 
+func newPassAllModeConfig(mode PassAllMode) passAllModeConfig {
+	return passAllModeConfig{
+		port:  byte(mode.Port),
+		j1708: mode.J1708,
+		j1587: mode.J1587,
+		can:   mode.CAN,
+		j1939: mode.J1939,
+	}
+}
+
 func (m passAllModeConfig) Write(p []byte) (int, error) {
 	if len(p) < 6 {
 		return 0, fmt.Errorf("byte slice length '%d' expected at least '6'", len(p))
@@ -48,6 +79,28 @@ func (m passAllModeConfig) Write(p []byte) (int, error) {
 	return 6, nil
 }
 
+// statsRequest asks the adapter to send its stats immediately rather than
+// waiting for the next periodic stats message. It is a bare message of the
+// stats type, 23. Only the stats the adapter sends periodically are known
+// from its traffic, so callers wait for the next stats message of either
+// kind, and an adapter ignoring the request is still found when its
+// periodic stats arrive.
+type statsRequest struct{}
+
+func (m statsRequest) Write(p []byte) (int, error) {
+	if len(p) < 1 {
+		return 0, fmt.Errorf("byte slice length '%d' expected at least '1'", len(p))
+	}
+
+	p[0] = 23
+
+	return 1, nil
+}
+
+// stats is the adapter's stats message, type 23, as it sends periodically:
+// the valid j1708 messages, invalid j1708 bytes and CAN frames it has
+// received as big endian 32 bit counts, then its hardware and software
+// versions, 15 bytes in all.
 type stats struct {
 	TotalValidJ1708Messages int
 	TotalInvalidJ1708Bytes  int
@@ -58,7 +111,7 @@ type stats struct {
 
 func newStats(m []byte) (*stats, error) {
 	if len(m) != 15 {
-		return nil, fmt.Errorf("stats message should be '11' got '%d'", len(m))
+		return nil, fmt.Errorf("stats message should be '15' got '%d'", len(m))
 	}
 
 	stats := stats{}
@@ -85,11 +138,17 @@ func newAck(message []byte) (*ack, error) {
 	return &ack{int(message[1])}, nil
 }
 
+// sendPriority is the j1708 bus access priority every message is sent with.
+// It is fixed, as neither Device.Send nor message expressions carry one.
+const sendPriority = 4
+
 type j1587Message struct {
-	Mid  int
-	Pid  int
-	Data []byte
-	Raw  []byte
+	Mid      int
+	Pid      int
+	Data     []byte
+	Raw      []byte
+	Received time.Time
+	Sequence uint64
 }
 
 func newJ1587Message(message []byte) (*j1587Message, error) {
@@ -97,43 +156,75 @@ func newJ1587Message(message []byte) (*j1587Message, error) {
 		return nil, fmt.Errorf("failed parsing j1587 message expected length > '2' got '%d'", len(message))
 	}
 
-	m := &j1587Message{}
-	m.Raw = []byte{}
+	raw := make([]byte, len(message)-1)
+	copy(raw, message[1:])
 
-	m.Mid = int(message[1])
-	m.Raw = append(m.Raw, message[1])
-
-	m.Raw = append(m.Raw, message[2])
-	if message[2] == 255 {
-		m.Raw = append(m.Raw, message[3])
-		m.Data = message[4:]
-		m.Pid = 256 + int(message[4])
-	} else {
-		m.Pid = int(message[2])
-		m.Data = message[3:]
+	m, err := common.ParseJ1587Message(raw)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed parsing j1587 message")
 	}
 
-	for _, b := range m.Data {
-		m.Raw = append(m.Raw, b)
+	return &j1587Message{
+		Mid:  m.Mid,
+		Pid:  m.Pid,
+		Data: m.Data,
+		Raw:  m.Raw,
+	}, nil
+}
+
+// j1708Frame is the body of a received bus message left unparsed. The adapter
+// has already checked and removed the frame's checksum.
+type j1708Frame struct {
+	Mid      int
+	Data     []byte
+	Received time.Time
+	Sequence uint64
+}
+
+func newJ1708Frame(message []byte) (*j1708Frame, error) {
+	if len(message) < 2 {
+		return nil, fmt.Errorf("failed parsing j1708 frame expected length > '1' got '%d'", len(message))
 	}
 
-	return m, nil
+	data := make([]byte, len(message)-2)
+	copy(data, message[2:])
+
+	return &j1708Frame{
+		Mid:  int(message[1]),
+		Data: data,
+	}, nil
 }
 
+// Write encodes the message for sending: the MID, the PID as two bytes, the
+// priority and the data. Only page 1 PIDs are written to the PID field. A
+// page 2 PID is written as the page extension PID 255 with the PID's own byte
+// leading the data, exactly as it appears on the bus, rather than as a PID
+// high byte of 1 the adapter is not known to accept.
 func (m *j1587Message) Write(p []byte) (int, error) {
-	r := 5 + len(m.Data)
+	if m.Pid < 0 || m.Pid > 511 {
+		return 0, fmt.Errorf("pid '%d' expected between '0' and '511'", m.Pid)
+	}
+
+	pid := m.Pid
+	data := m.Data
+	if pid > 255 {
+		data = append([]byte{byte(pid - 256)}, data...)
+		pid = 255
+	}
+
+	r := 5 + len(data)
 	if len(p) < r {
-		return 0, fmt.Errorf("byte slice length '%d' expected at least '6'", r)
+		return 0, fmt.Errorf("byte slice length '%d' expected at least '%d'", len(p), r)
 	}
 
 	p[0] = 8
 	p[1] = byte(m.Mid)
-	p[2] = byte(m.Pid >> 8)
-	p[3] = byte(m.Pid)
-	p[4] = 4
+	p[2] = 0
+	p[3] = byte(pid)
+	p[4] = sendPriority
 
 	i := 5
-	for _, b := range m.Data {
+	for _, b := range data {
 		p[i] = b
 		i++
 	}
@@ -0,0 +1,79 @@
+package simma
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/syncromatics/j1708-tester/pkg/common"
+)
+
+func TestJ1587MessagePage2RoundTrip(t *testing.T) {
+	received := make(chan *common.J1587Message, 1)
+	d, adapter := openFakeDevice(t, func(m *common.J1587Message) {
+		received <- m
+	})
+
+	d.mtx.Lock()
+	p := d.protocol
+	d.mtx.Unlock()
+
+	err := p.Send(&j1587Message{Mid: 128, Pid: 259, Data: []byte{1}})
+	if err != nil {
+		t.Fatalf("send failed: %v", err)
+	}
+
+	tr := <-adapter.Transmitted()
+	if tr.Mid != 128 || tr.Pid != 255 || tr.Priority != sendPriority || !bytes.Equal(tr.Data, []byte{3, 1}) {
+		t.Fatalf("transmitted %+v expected MID 128 PID 255 data [3 1]", tr)
+	}
+
+	frame := tr.Frame()
+	err = adapter.InjectJ1708(int(frame[0]), frame[1:])
+	if err != nil {
+		t.Fatalf("inject failed: %v", err)
+	}
+
+	select {
+	case m := <-received:
+		if m.Mid != 128 || m.Pid != 259 || !bytes.Equal(m.Data, []byte{1}) || !bytes.Equal(m.Raw, []byte{128, 255, 3, 1}) {
+			t.Errorf("received %+v expected MID 128 PID 259 data [1] raw [128 255 3 1]", m)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing received")
+	}
+}
+
+func TestJ1587MessageWrite(t *testing.T) {
+	tests := []struct {
+		name    string
+		message *j1587Message
+		bytes   []byte
+	}{
+		{"page 1", &j1587Message{Mid: 128, Pid: 84, Data: []byte{100}}, []byte{8, 128, 0, 84, sendPriority, 100}},
+		{"page 2", &j1587Message{Mid: 128, Pid: 259, Data: []byte{1}}, []byte{8, 128, 0, 255, sendPriority, 3, 1}},
+		{"page 2 without data", &j1587Message{Mid: 128, Pid: 256}, []byte{8, 128, 0, 255, sendPriority, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := make([]byte, 32)
+			n, err := tt.message.Write(p)
+			if err != nil {
+				t.Fatalf("write failed: %v", err)
+			}
+			if !bytes.Equal(p[:n], tt.bytes) {
+				t.Errorf("expected %v got %v", tt.bytes, p[:n])
+			}
+		})
+	}
+
+	_, err := (&j1587Message{Mid: 128, Pid: 512}).Write(make([]byte, 32))
+	if err == nil {
+		t.Error("expected an error for PID '512'")
+	}
+	_, err = (&j1587Message{Mid: 128, Pid: 259, Data: []byte{1}}).Write(make([]byte, 6))
+	if err == nil {
+		t.Error("expected an error for a buffer too short for the page 2 byte")
+	}
+}
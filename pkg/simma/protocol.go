@@ -11,41 +11,76 @@ import (
 	"github.com/pkg/errors"
 )
 
+const sendRetries = 3
+
+// ackTimeout is how long each try waits for the adapter's ack.
+var ackTimeout = 3 * time.Second
+
+// AckTimeoutError is returned when the adapter does not acknowledge a message
+// after every retry.
+type AckTimeoutError struct {
+	MessageIdentifier int
+	Retries           int
+}
+
+func (e *AckTimeoutError) Error() string {
+	return fmt.Sprintf("failed to receive ack after %d retries", e.Retries)
+}
+
+// IsAckTimeout reports whether err, or the error it wraps, is an
+// AckTimeoutError.
+func IsAckTimeout(err error) bool {
+	_, ok := errors.Cause(err).(*AckTimeoutError)
+	return ok
+}
+
 type protocol struct {
 	writeMtx    *sync.Mutex
 	writeBuffer []byte
 
 	channel      *channel
 	acks         chan *ack
+	stats        chan *stats
 	j1587Handler func(*j1587Message)
 	badBytes     int
+
+	// sequence numbers the bus messages received, not counting acks, stats
+	// or other adapter frames.
+	sequence uint64
+
+	// j1708Handler, when set, receives bus messages instead of j1587Handler
+	// without them being parsed as j1587.
+	j1708Handler func(*j1708Frame)
 }
 
-func newProtocol(port string, j1587Handler func(*j1587Message)) (*protocol, error) {
+func newProtocol(portName string, port io.ReadWriteCloser, j1587Handler func(*j1587Message)) *protocol {
 	p := &protocol{
 		writeMtx:     new(sync.Mutex),
 		writeBuffer:  make([]byte, 2000),
-		acks:         make(chan *ack),
+		acks:         make(chan *ack, 1),
+		stats:        make(chan *stats, 1),
 		j1587Handler: j1587Handler,
 	}
 
-	c, err := openChannel(port, p.parseMessage)
-	if err != nil {
-		return nil, errors.Wrap(err, "failed opening channel")
-	}
-
-	p.channel = c
+	p.channel = openChannel(portName, port)
 
-	return p, nil
+	return p
 }
 
 func (p *protocol) Start(ctx context.Context) func() error {
 	return func() error {
-		for {
-			select {
-			case <-ctx.Done():
-				return nil
-			}
+		defer p.channel.close()
+
+		errs := make(chan error, 1)
+		go func() {
+			errs <- p.channel.connect(p.parseMessage)
+		}()
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return errors.Wrap(err, "channel closed")
 		}
 	}
 }
@@ -59,13 +94,18 @@ func (p *protocol) Send(message io.Writer) error {
 		return errors.Wrap(err, "failed to write to out buffer")
 	}
 
-	for i := 0; i < 3; i++ {
+	select {
+	case <-p.acks: // drop an ack that arrived after its sender gave up
+	default:
+	}
+
+	for i := 0; i < sendRetries; i++ {
 		err = p.channel.write(p.writeBuffer[:l])
 		if err != nil {
 			return errors.Wrap(err, "failed writing to channel")
 		}
 
-		timer := time.NewTimer(3 * time.Second)
+		timer := time.NewTimer(ackTimeout)
 
 		loop := true
 		for loop {
@@ -81,10 +121,40 @@ func (p *protocol) Send(message io.Writer) error {
 		}
 	}
 
-	return fmt.Errorf("failed to receive ack after 3 retries")
+	return &AckTimeoutError{
+		MessageIdentifier: int(p.writeBuffer[0]),
+		Retries:           sendRetries,
+	}
 }
 
-func (p *protocol) parseMessage(message []byte) {
+// RequestStats asks the adapter for its stats and waits for the next stats
+// message.
+func (p *protocol) RequestStats(timeout time.Duration) (*stats, error) {
+	select {
+	case <-p.stats:
+	default:
+	}
+
+	b := make([]byte, 1)
+	l, err := statsRequest{}.Write(b)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to write stats request")
+	}
+
+	err = p.channel.write(b[:l])
+	if err != nil {
+		return nil, errors.Wrap(err, "failed writing to channel")
+	}
+
+	select {
+	case s := <-p.stats:
+		return s, nil
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("failed to receive stats after %v", timeout)
+	}
+}
+
+func (p *protocol) parseMessage(message []byte, received time.Time) {
 	switch message[0] {
 	case 0:
 		ack, err := newAck(message)
@@ -93,14 +163,35 @@ func (p *protocol) parseMessage(message []byte) {
 			return
 		}
 
-		p.acks <- ack
+		select {
+		case p.acks <- ack:
+		default:
+			log.Printf("warn: dropped unexpected ack for message %d", ack.MessageIdentifier)
+		}
 		break
 	case 22:
+		p.sequence++
+
+		if p.j1708Handler != nil {
+			f, err := newJ1708Frame(message)
+			if err != nil {
+				log.Printf("warn: %v", err)
+				return
+			}
+			f.Received = received
+			f.Sequence = p.sequence
+
+			p.j1708Handler(f)
+			break
+		}
+
 		m, err := newJ1587Message(message)
 		if err != nil {
 			log.Printf("warn: %v", err)
 			return
 		}
+		m.Received = received
+		m.Sequence = p.sequence
 
 		p.j1587Handler(m)
 
@@ -116,6 +207,12 @@ func (p *protocol) parseMessage(message []byte) {
 			log.Printf("warn: invalid j1708 bytes received %d", stats.TotalInvalidJ1708Bytes)
 			p.badBytes = stats.TotalInvalidJ1708Bytes
 		}
+
+		select {
+		case <-p.stats: // keep only the latest stats
+		default:
+		}
+		p.stats <- stats
 		break
 	default:
 		break
@@ -0,0 +1,44 @@
+package simma
+
+import (
+	"io"
+
+	"github.com/jacobsa/go-serial/serial"
+	"github.com/pkg/errors"
+)
+
+type SerialConfig struct {
+	Port            string
+	BaudRate        uint
+	DataBits        uint
+	StopBits        uint
+	MinimumReadSize uint
+}
+
+// DefaultSerialConfig returns the settings used by the VNA2-USB.
+func DefaultSerialConfig(port string) SerialConfig {
+	return SerialConfig{
+		Port:            port,
+		BaudRate:        115200,
+		DataBits:        8,
+		StopBits:        1,
+		MinimumReadSize: 4,
+	}
+}
+
+func openSerialPort(config SerialConfig) (io.ReadWriteCloser, error) {
+	options := serial.OpenOptions{
+		PortName:        config.Port,
+		BaudRate:        config.BaudRate,
+		DataBits:        config.DataBits,
+		StopBits:        config.StopBits,
+		MinimumReadSize: config.MinimumReadSize,
+	}
+
+	port, err := serial.Open(options)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed opening port '%s'", config.Port)
+	}
+
+	return port, nil
+}